@@ -0,0 +1,9 @@
+// Package db 内嵌数据库迁移脚本，由 pkg 中的迁移逻辑执行
+package db
+
+import "embed"
+
+// 版本化迁移脚本，文件名格式为 NNNN_name.up.sql / NNNN_name.down.sql
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS benchmark;
+DROP TABLE IF EXISTS paper;
+DROP TABLE IF EXISTS software;
@@ -0,0 +1,5 @@
+-- 初始表结构（原 create_tables.sql）
+CREATE TABLE IF NOT EXISTS software (id SERIAL PRIMARY KEY,name VARCHAR(200) NOT NULL UNIQUE,abstract TEXT,homepage TEXT,github TEXT,categories TEXT[],tags TEXT[],created_at TIMESTAMP DEFAULT NOW());
+CREATE TABLE IF NOT EXISTS paper (id varchar(64) PRIMARY KEY,title TEXT NOT NULL,authors TEXT[],abstract TEXT,url TEXT,pdf TEXT,software_names TEXT[],created_at TIMESTAMP DEFAULT NOW());
+CREATE TABLE IF NOT EXISTS benchmark (id SERIAL PRIMARY KEY,software_id INT NOT NULL,name TEXT,dataset TEXT,hardware JSONB,metrics JSONB,version TEXT,created_at TIMESTAMP DEFAULT NOW());
+CREATE UNIQUE INDEX IF NOT EXISTS unique_software_idx ON software (name);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS benchmark_software_id_idx;
+ALTER TABLE benchmark DROP CONSTRAINT IF EXISTS benchmark_software_id_fkey;
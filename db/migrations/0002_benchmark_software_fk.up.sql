@@ -0,0 +1,15 @@
+-- benchmark.software_id 外键。存在孤立数据时中止迁移并列出 ID，由人工处理（补回软件或删除 benchmark）后再执行
+DO $$
+DECLARE
+    orphans TEXT;
+BEGIN
+    SELECT string_agg(b.id::text, ', ' ORDER BY b.id) INTO orphans
+    FROM benchmark b WHERE NOT EXISTS (SELECT 1 FROM software s WHERE s.id = b.software_id);
+    IF orphans IS NOT NULL THEN
+        RAISE EXCEPTION 'benchmark rows reference missing software, fix them before migrating: %', orphans;
+    END IF;
+END $$;
+ALTER TABLE benchmark DROP CONSTRAINT IF EXISTS benchmark_software_id_fkey;
+-- 有 benchmark 的软件不能删除，需先删除其 benchmark
+ALTER TABLE benchmark ADD CONSTRAINT benchmark_software_id_fkey FOREIGN KEY (software_id) REFERENCES software(id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS benchmark_software_id_idx ON benchmark (software_id);
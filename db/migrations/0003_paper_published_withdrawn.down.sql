@@ -0,0 +1,2 @@
+ALTER TABLE paper DROP COLUMN IF EXISTS withdrawn;
+ALTER TABLE paper DROP COLUMN IF EXISTS published_time;
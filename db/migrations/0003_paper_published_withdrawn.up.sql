@@ -0,0 +1,3 @@
+-- paper.published_time / paper.withdrawn
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS published_time TEXT;
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS withdrawn BOOLEAN NOT NULL DEFAULT FALSE;
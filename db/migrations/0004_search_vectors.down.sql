@@ -0,0 +1,4 @@
+ALTER TABLE benchmark DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE paper DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE software DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS immutable_array_to_string(text[], text);
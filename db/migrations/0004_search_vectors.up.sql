@@ -0,0 +1,24 @@
+-- 全文检索：tsvector 生成列 + GIN 索引
+-- array_to_string 不是 IMMUTABLE，生成列里需要包一层
+CREATE OR REPLACE FUNCTION immutable_array_to_string(text[], text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$ SELECT array_to_string($1, $2) $$;
+
+ALTER TABLE software ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(immutable_array_to_string(tags, ' '), '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(abstract, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS software_search_idx ON software USING GIN (search_vector);
+
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(immutable_array_to_string(authors, ' '), '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(abstract, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS paper_search_idx ON paper USING GIN (search_vector);
+
+ALTER TABLE benchmark ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(dataset, '')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS benchmark_search_idx ON benchmark USING GIN (search_vector);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS crawl_job;
@@ -0,0 +1,2 @@
+-- 异步抓取任务
+CREATE TABLE IF NOT EXISTS crawl_job (id BIGSERIAL PRIMARY KEY,state TEXT NOT NULL,softwares JSONB NOT NULL DEFAULT '[]',found INT NOT NULL DEFAULT 0,inserted INT NOT NULL DEFAULT 0,updated INT NOT NULL DEFAULT 0,skipped INT NOT NULL DEFAULT 0,failed INT NOT NULL DEFAULT 0,error TEXT NOT NULL DEFAULT '',created_at TIMESTAMP NOT NULL DEFAULT NOW(),started_at TIMESTAMP,finished_at TIMESTAMP);
@@ -0,0 +1,5 @@
+ALTER TABLE paper DROP COLUMN IF EXISTS journal_ref;
+ALTER TABLE paper DROP COLUMN IF EXISTS doi;
+ALTER TABLE paper DROP COLUMN IF EXISTS categories;
+ALTER TABLE paper DROP COLUMN IF EXISTS latest_version;
+ALTER TABLE paper DROP COLUMN IF EXISTS updated_time;
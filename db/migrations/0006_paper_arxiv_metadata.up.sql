@@ -0,0 +1,6 @@
+-- arXiv Atom API 提供的额外元数据
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS updated_time TEXT;
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS latest_version INT;
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS categories TEXT[];
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS doi TEXT;
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS journal_ref TEXT;
@@ -0,0 +1,4 @@
+-- 不缩短 paper.id：已有 "doi:..." 形式的 ID 时会失败
+DROP INDEX IF EXISTS paper_doi_idx;
+ALTER TABLE paper DROP COLUMN IF EXISTS external_ids;
+ALTER TABLE paper DROP COLUMN IF EXISTS source;
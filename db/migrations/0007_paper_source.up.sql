@@ -0,0 +1,6 @@
+-- 多来源论文：来源、各来源的标识，ID 加长以容纳 "doi:..." 形式
+ALTER TABLE paper ALTER COLUMN id TYPE VARCHAR(255);
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'arxiv';
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS external_ids JSONB NOT NULL DEFAULT '{}';
+UPDATE paper SET external_ids = jsonb_build_object('arxiv', id) WHERE source = 'arxiv' AND external_ids = '{}';
+CREATE INDEX IF NOT EXISTS paper_doi_idx ON paper (LOWER(doi));
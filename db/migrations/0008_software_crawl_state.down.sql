@@ -0,0 +1,2 @@
+ALTER TABLE crawl_job DROP COLUMN IF EXISTS "full";
+DROP TABLE IF EXISTS software_crawl_state;
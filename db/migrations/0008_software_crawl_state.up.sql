@@ -0,0 +1,3 @@
+-- 增量抓取：记录每个软件上次抓取的时间和见过的最新 arXiv ID
+CREATE TABLE IF NOT EXISTS software_crawl_state (software_id INT PRIMARY KEY REFERENCES software(id) ON DELETE CASCADE,last_crawled_at TIMESTAMP,last_success_at TIMESTAMP,newest_arxiv_id TEXT NOT NULL DEFAULT '');
+ALTER TABLE crawl_job ADD COLUMN IF NOT EXISTS "full" BOOLEAN NOT NULL DEFAULT FALSE;
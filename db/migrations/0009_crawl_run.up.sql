@@ -0,0 +1,2 @@
+-- 定时抓取的执行记录
+CREATE TABLE IF NOT EXISTS crawl_run (id BIGSERIAL PRIMARY KEY,state TEXT NOT NULL,job_id BIGINT REFERENCES crawl_job(id) ON DELETE SET NULL,error TEXT NOT NULL DEFAULT '',started_at TIMESTAMP NOT NULL DEFAULT NOW(),finished_at TIMESTAMP);
@@ -0,0 +1,5 @@
+ALTER TABLE crawl_job DROP COLUMN IF EXISTS queued;
+DROP TABLE IF EXISTS paper_review;
+ALTER TABLE paper DROP COLUMN IF EXISTS software_confidence;
+ALTER TABLE software DROP COLUMN IF EXISTS exclude_terms;
+ALTER TABLE software DROP COLUMN IF EXISTS aliases;
@@ -0,0 +1,7 @@
+-- 检索别名、排除词，论文—软件关联的置信度和人工审核队列
+ALTER TABLE software ADD COLUMN IF NOT EXISTS aliases TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE software ADD COLUMN IF NOT EXISTS exclude_terms TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS software_confidence JSONB NOT NULL DEFAULT '{}';
+CREATE TABLE IF NOT EXISTS paper_review (id BIGSERIAL PRIMARY KEY,paper_id VARCHAR(255) NOT NULL REFERENCES paper(id) ON DELETE CASCADE,software_name TEXT NOT NULL,confidence REAL NOT NULL,reasons TEXT[] NOT NULL DEFAULT '{}',status TEXT NOT NULL DEFAULT 'pending',created_at TIMESTAMP NOT NULL DEFAULT NOW(),reviewed_at TIMESTAMP,UNIQUE (paper_id, software_name));
+CREATE INDEX IF NOT EXISTS paper_review_status_idx ON paper_review (status, id);
+ALTER TABLE crawl_job ADD COLUMN IF NOT EXISTS queued INT NOT NULL DEFAULT 0;
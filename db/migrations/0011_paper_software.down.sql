@@ -0,0 +1,27 @@
+-- 从 paper_software 还原 software_names 数组和置信度
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS software_names TEXT[];
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS software_confidence JSONB NOT NULL DEFAULT '{}';
+UPDATE paper p SET
+    software_names = ARRAY(SELECT s.name FROM paper_software ps JOIN software s ON s.id = ps.software_id
+                           WHERE ps.paper_id = p.id ORDER BY ps.created_at, s.name)
+                     || ARRAY(SELECT u.software_name FROM paper_software_unmatched u WHERE u.paper_id = p.id ORDER BY u.software_name),
+    software_confidence = COALESCE((SELECT jsonb_object_agg(s.name, ps.confidence) FROM paper_software ps
+                                    JOIN software s ON s.id = ps.software_id
+                                    WHERE ps.paper_id = p.id AND ps.confidence IS NOT NULL), '{}')
+                          || COALESCE((SELECT jsonb_object_agg(u.software_name, u.confidence) FROM paper_software_unmatched u
+                                       WHERE u.paper_id = p.id AND u.confidence IS NOT NULL), '{}');
+
+ALTER TABLE paper_review ADD COLUMN IF NOT EXISTS software_name TEXT;
+UPDATE paper_review r SET software_name = s.name FROM software s WHERE s.id = r.software_id;
+ALTER TABLE paper_review ALTER COLUMN software_name SET NOT NULL;
+DROP INDEX IF EXISTS paper_review_paper_id_software_id_key;
+ALTER TABLE paper_review DROP COLUMN software_id;
+ALTER TABLE paper_review ADD CONSTRAINT paper_review_paper_id_software_name_key UNIQUE (paper_id, software_name);
+INSERT INTO paper_review (id, paper_id, software_name, confidence, reasons, status, created_at, reviewed_at)
+SELECT id, paper_id, software_name, confidence, reasons, status, created_at, reviewed_at FROM paper_review_unmatched
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS paper_review_unmatched;
+DROP TABLE IF EXISTS paper_software_unmatched;
+
+DROP TABLE IF EXISTS paper_software;
@@ -0,0 +1,61 @@
+-- 论文—软件关联改为 paper_software 关联表，替代 paper.software_names 数组
+-- 无法匹配到软件的名称和审核记录不丢弃，分别保存到 paper_software_unmatched 和 paper_review_unmatched，
+-- 补建软件后可据此重新关联，确认无用后再手动删除这两张表
+CREATE TABLE IF NOT EXISTS paper_software (paper_id VARCHAR(255) NOT NULL REFERENCES paper(id) ON DELETE CASCADE,software_id INT NOT NULL REFERENCES software(id) ON DELETE CASCADE,source TEXT NOT NULL DEFAULT 'crawl',confidence REAL,created_at TIMESTAMP NOT NULL DEFAULT NOW(),PRIMARY KEY (paper_id, software_id));
+CREATE INDEX IF NOT EXISTS paper_software_software_id_idx ON paper_software (software_id);
+CREATE TABLE IF NOT EXISTS paper_software_unmatched (paper_id VARCHAR(255) NOT NULL REFERENCES paper(id) ON DELETE CASCADE,software_name TEXT NOT NULL,confidence REAL,archived_at TIMESTAMP NOT NULL DEFAULT NOW(),PRIMARY KEY (paper_id, software_name));
+CREATE TABLE IF NOT EXISTS paper_review_unmatched (id BIGINT PRIMARY KEY,paper_id VARCHAR(255) NOT NULL REFERENCES paper(id) ON DELETE CASCADE,software_name TEXT NOT NULL,confidence REAL NOT NULL,reasons TEXT[] NOT NULL DEFAULT '{}',status TEXT NOT NULL,created_at TIMESTAMP NOT NULL,reviewed_at TIMESTAMP,archived_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+-- 已按旧脚本手动迁移过的库没有 software_names 列，跳过回填
+DO $$
+DECLARE
+    n BIGINT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'paper' AND column_name = 'software_names') THEN
+        INSERT INTO paper_software (paper_id, software_id, source, confidence, created_at)
+        SELECT p.id, s.id, 'backfill', (p.software_confidence ->> sn)::real, COALESCE(p.created_at, NOW())
+        FROM paper p
+        CROSS JOIN LATERAL unnest(p.software_names) sn
+        JOIN software s ON LOWER(s.name) = LOWER(sn)
+        ON CONFLICT (paper_id, software_id) DO NOTHING;
+
+        INSERT INTO paper_software_unmatched (paper_id, software_name, confidence)
+        SELECT p.id, sn, (p.software_confidence ->> sn)::real
+        FROM paper p
+        CROSS JOIN LATERAL unnest(p.software_names) sn
+        WHERE NOT EXISTS (SELECT 1 FROM software s WHERE LOWER(s.name) = LOWER(sn))
+        ON CONFLICT (paper_id, software_name) DO NOTHING;
+        GET DIAGNOSTICS n = ROW_COUNT;
+        IF n > 0 THEN
+            RAISE WARNING '% paper software names match no software, archived in paper_software_unmatched', n;
+        END IF;
+    END IF;
+END $$;
+
+-- 审核队列同样改为引用软件 ID
+ALTER TABLE paper_review ADD COLUMN IF NOT EXISTS software_id INT REFERENCES software(id) ON DELETE CASCADE;
+DO $$
+DECLARE
+    n BIGINT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'paper_review' AND column_name = 'software_name') THEN
+        UPDATE paper_review r SET software_id = s.id FROM software s WHERE r.software_id IS NULL AND LOWER(s.name) = LOWER(r.software_name);
+
+        INSERT INTO paper_review_unmatched (id, paper_id, software_name, confidence, reasons, status, created_at, reviewed_at)
+        SELECT id, paper_id, software_name, confidence, reasons, status, created_at, reviewed_at
+        FROM paper_review WHERE software_id IS NULL
+        ON CONFLICT (id) DO NOTHING;
+        GET DIAGNOSTICS n = ROW_COUNT;
+        IF n > 0 THEN
+            RAISE WARNING '% paper reviews match no software, archived in paper_review_unmatched', n;
+        END IF;
+    END IF;
+END $$;
+-- 只删除已归档的记录，还有未归档的说明库的状态与预期不符，由 NOT NULL 约束中止迁移
+DELETE FROM paper_review r WHERE r.software_id IS NULL AND EXISTS (SELECT 1 FROM paper_review_unmatched u WHERE u.id = r.id);
+ALTER TABLE paper_review ALTER COLUMN software_id SET NOT NULL;
+ALTER TABLE paper_review DROP COLUMN IF EXISTS software_name;
+CREATE UNIQUE INDEX IF NOT EXISTS paper_review_paper_id_software_id_key ON paper_review (paper_id, software_id);
+
+ALTER TABLE paper DROP COLUMN IF EXISTS software_names;
+ALTER TABLE paper DROP COLUMN IF EXISTS software_confidence;
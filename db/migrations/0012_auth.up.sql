@@ -0,0 +1,4 @@
+-- 用户和 API key，key 只保存 SHA-256 哈希，prefix 用于展示和辨认
+CREATE TABLE IF NOT EXISTS users (id SERIAL PRIMARY KEY,name TEXT NOT NULL UNIQUE,role TEXT NOT NULL CHECK (role IN ('viewer', 'curator', 'admin')),disabled BOOLEAN NOT NULL DEFAULT FALSE,created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE TABLE IF NOT EXISTS api_keys (id SERIAL PRIMARY KEY,user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,name TEXT NOT NULL DEFAULT '',prefix TEXT NOT NULL,key_hash TEXT NOT NULL UNIQUE,created_at TIMESTAMP NOT NULL DEFAULT NOW(),last_used_at TIMESTAMP,revoked_at TIMESTAMP);
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS paper_published_date_idx;
+ALTER TABLE paper DROP COLUMN IF EXISTS published_date;
@@ -0,0 +1,19 @@
+-- 发表日期单独存为 DATE 列，由程序在写入时从 published_time 解析，日期过滤可以走索引
+ALTER TABLE paper ADD COLUMN IF NOT EXISTS published_date DATE;
+
+-- 回填已有数据，规则与程序中的 publishedDate 相同；无法解析的保留为 NULL，不中断迁移
+CREATE FUNCTION pg_temp.parse_published_date(t TEXT) RETURNS DATE LANGUAGE plpgsql AS $$
+BEGIN
+    IF t ~ '^\d{4}-\d{2}-\d{2}' THEN
+        RETURN to_date(substring(t from 1 for 10), 'YYYY-MM-DD');
+    ELSIF t ~ '^[A-Za-z]{3}, \d{1,2} [A-Za-z]{3} \d{4}' THEN
+        RETURN to_date(substring(t from 6), 'DD Mon YYYY');
+    END IF;
+    RETURN NULL;
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END $$;
+UPDATE paper SET published_date = pg_temp.parse_published_date(published_time)
+WHERE published_time IS NOT NULL AND published_date IS NULL;
+
+CREATE INDEX IF NOT EXISTS paper_published_date_idx ON paper (published_date);
@@ -0,0 +1 @@
+ALTER TABLE crawl_job DROP COLUMN IF EXISTS cancel_requested;
@@ -0,0 +1,2 @@
+-- 取消请求持久化，由执行任务的实例在处理每页之间检查，任意实例都能受理取消
+ALTER TABLE crawl_job ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
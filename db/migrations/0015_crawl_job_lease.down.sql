@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS crawl_job_unfinished_idx;
+ALTER TABLE crawl_job DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE crawl_job DROP COLUMN IF EXISTS instance_id;
@@ -0,0 +1,4 @@
+-- 任务的持有实例和租约，持有实例定期续约；租约过期说明该实例已退出，其他实例才会将任务标记为失败或认领
+ALTER TABLE crawl_job ADD COLUMN IF NOT EXISTS instance_id TEXT;
+ALTER TABLE crawl_job ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS crawl_job_unfinished_idx ON crawl_job (lease_expires_at) WHERE state IN ('queued', 'running', 'interrupted');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS software_name_lower_idx;
@@ -0,0 +1,13 @@
+-- 软件名不区分大小写唯一，按名称查找软件和关联论文时比较 LOWER(name)。
+-- 已有仅大小写不同的软件时中止迁移并列出，由人工合并或改名后再执行
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(names, '; ') INTO duplicates
+    FROM (SELECT string_agg(name, ', ' ORDER BY id) AS names FROM software GROUP BY LOWER(name) HAVING COUNT(*) > 1) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'software names differ only in case, merge or rename them before migrating: %', duplicates;
+    END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS software_name_lower_idx ON software (LOWER(name));
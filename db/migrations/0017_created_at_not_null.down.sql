@@ -0,0 +1,3 @@
+ALTER TABLE benchmark ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE software ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE paper ALTER COLUMN created_at DROP NOT NULL;
@@ -0,0 +1,8 @@
+-- 列表按 created_at 分页时 keyset 游标的 (created_at, id) 比较会漏掉 created_at 为 NULL 的行，读取时也无法扫描 NULL。
+-- 早期数据的 created_at 可能为 NULL，回填为 epoch（按创建时间排在最前），之后不允许为空
+UPDATE paper SET created_at = 'epoch' WHERE created_at IS NULL;
+ALTER TABLE paper ALTER COLUMN created_at SET NOT NULL;
+UPDATE software SET created_at = 'epoch' WHERE created_at IS NULL;
+ALTER TABLE software ALTER COLUMN created_at SET NOT NULL;
+UPDATE benchmark SET created_at = 'epoch' WHERE created_at IS NULL;
+ALTER TABLE benchmark ALTER COLUMN created_at SET NOT NULL;
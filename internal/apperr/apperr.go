@@ -0,0 +1,91 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 错误类别，决定 HTTP 状态码和错误响应中的 code
+type Code string
+
+const (
+	NotFound        Code = "not_found"
+	Conflict        Code = "conflict"
+	Validation      Code = "validation_failed"
+	Unauthenticated Code = "unauthenticated"
+	Forbidden       Code = "forbidden"
+	Unavailable     Code = "upstream_unavailable"
+	Timeout         Code = "timeout"
+	Internal        Code = "internal"
+)
+
+// 领域错误。Message 和 Details 会返回给调用方，Err 是内部原因，只写日志
+type Error struct {
+	Code    Code
+	Message string
+	Details any
+	Err     error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Errorf(code Code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// 带内部原因的错误，err 不会出现在响应中
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// target 的 Message 为空时匹配同类别的任意错误，否则要求类别和 Message 都相同，
+// 因此 WithDetails、WithCause 得到的副本仍然匹配原来的哨兵错误
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Code != e.Code {
+		return false
+	}
+	return t.Message == "" || t.Message == e.Message
+}
+
+// 返回附带 details 的副本
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// 返回以 err 为内部原因的副本
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// err 链上的第一个领域错误
+func From(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// 错误是否属于 code 类别
+func IsCode(err error, code Code) bool {
+	e, ok := From(err)
+	return ok && e.Code == code
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"hpc-site/internal/models"
+)
+
+const (
+	apiKeyPrefix = "hpc_"
+	tokenIssuer  = "hpc-site"
+
+	// 外部提供的 key（ADMIN_API_KEY）前缀之后至少要有这么多字符，估算的熵不低于 minAPIKeyBits；
+	// 服务生成的 key 为 43 个字符、256 位
+	minAPIKeySecretLen = 32
+	minAPIKeyBits      = 128
+)
+
+// 未配置 JWT_SECRET 时不签发也不接受 JWT
+var ErrJWTDisabled = errors.New("jwt signing key not configured")
+
+// 由 config.Auth 转换而来
+type Config struct {
+	JWTSecret   []byte
+	TokenTTL    time.Duration
+	AdminAPIKey string // 启动时确保存在的管理员 key，为空时不处理
+}
+
+// 通过认证的调用方
+type Principal struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Method string `json:"method"` // api_key 或 jwt
+	KeyID  int    `json:"key_id,omitempty"`
+}
+
+// 生成新的 API key，返回明文、用于展示的前缀和存储的哈希
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, APIKeyPrefix(key), HashAPIKey(key), nil
+}
+
+// key 是 32 字节随机数，不需要加盐和慢哈希
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// 凭证是否为 API key（否则按 JWT 处理）
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+// 检查外部提供的 API key 是否足够随机：hpc_ 前缀之后至少 32 个 base64url 字符，
+// 按长度和不同字符数估算的熵不低于 128 位，hpc_x、hpc_aaaa... 这样的 key 都会被拒绝
+func ValidateAPIKey(key string) error {
+	secret, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return fmt.Errorf("api key must start with %s", apiKeyPrefix)
+	}
+	if len(secret) < minAPIKeySecretLen {
+		return fmt.Errorf("api key must have at least %d characters after %s, got %d", minAPIKeySecretLen, apiKeyPrefix, len(secret))
+	}
+	distinct := make(map[rune]bool)
+	for _, r := range secret {
+		if !strings.ContainsRune(base64URLAlphabet, r) {
+			return fmt.Errorf("api key may only contain base64url characters (A-Z a-z 0-9 - _) after %s", apiKeyPrefix)
+		}
+		distinct[r] = true
+	}
+	if bits := float64(len(secret)) * math.Log2(float64(len(distinct))); bits < minAPIKeyBits {
+		return fmt.Errorf("api key is too predictable (about %.0f bits, need %d), generate at least 32 random bytes", bits, minAPIKeyBits)
+	}
+	return nil
+}
+
+const base64URLAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// 前缀，ADMIN_API_KEY 等外部提供的 key 也按同样的规则截取
+func APIKeyPrefix(key string) string {
+	return key[:min(len(key), len(apiKeyPrefix)+8)]
+}
+
+type claims struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// 为用户签发 JWT，返回 token 和过期时间
+func (c Config) IssueToken(user *models.User) (string, time.Time, error) {
+	if len(c.JWTSecret) == 0 {
+		return "", time.Time{}, ErrJWTDisabled
+	}
+	now := time.Now()
+	expires := now.Add(c.TokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Name: user.Name,
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	})
+	signed, err := token.SignedString(c.JWTSecret)
+	return signed, expires, err
+}
+
+// 本地校验 JWT 的签名、签发方和有效期。token 中的角色是签发时的快照，
+// 调用方需按 UserID 重新确认用户的当前角色和停用状态
+func (c Config) ParseToken(token string) (*Principal, error) {
+	if len(c.JWTSecret) == 0 {
+		return nil, ErrJWTDisabled
+	}
+	var cl claims
+	_, err := jwt.ParseWithClaims(token, &cl, func(*jwt.Token) (any, error) {
+		return c.JWTSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(cl.Subject)
+	if err != nil || !models.ValidRole(cl.Role) {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	return &Principal{UserID: id, Name: cl.Name, Role: cl.Role, Method: "jwt"}, nil
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"hpc-site/internal/models"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// 用任意算法、密钥和 claims 签发 token，模拟伪造或过期的凭证
+func signToken(t *testing.T, method jwt.SigningMethod, key any, cl claims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(method, cl).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func validClaims() claims {
+	now := time.Now()
+	return claims{
+		Name: "alice",
+		Role: models.RoleCurator,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   "7",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func TestIssueAndParseToken(t *testing.T) {
+	c := Config{JWTSecret: testSecret, TokenTTL: time.Hour}
+	token, expires, err := c.IssueToken(&models.User{ID: 7, Name: "alice", Role: models.RoleCurator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(expires); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("expires in %s", d)
+	}
+	p, err := c.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Principal{UserID: 7, Name: "alice", Role: models.RoleCurator, Method: "jwt"}
+	if *p != want {
+		t.Errorf("principal = %+v, want %+v", *p, want)
+	}
+
+	// 未配置密钥时不签发也不接受
+	disabled := Config{TokenTTL: time.Hour}
+	if _, _, err := disabled.IssueToken(&models.User{ID: 7, Role: models.RoleAdmin}); !errors.Is(err, ErrJWTDisabled) {
+		t.Errorf("IssueToken without secret: %v", err)
+	}
+	if _, err := disabled.ParseToken(token); !errors.Is(err, ErrJWTDisabled) {
+		t.Errorf("ParseToken without secret: %v", err)
+	}
+}
+
+func TestParseTokenRejects(t *testing.T) {
+	c := Config{JWTSecret: testSecret, TokenTTL: time.Hour}
+	with := func(modify func(*claims)) claims {
+		cl := validClaims()
+		modify(&cl)
+		return cl
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+	}{
+		{"expired", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(cl *claims) {
+			cl.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}))},
+		{"no expiry", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(cl *claims) { cl.ExpiresAt = nil }))},
+		{"wrong secret", signToken(t, jwt.SigningMethodHS256, []byte("another-secret-of-32-bytes-long!"), validClaims())},
+		{"wrong alg HS512", signToken(t, jwt.SigningMethodHS512, testSecret, validClaims())},
+		{"alg none", signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims())},
+		{"wrong issuer", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(cl *claims) { cl.Issuer = "someone-else" }))},
+		{"unknown role", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(cl *claims) { cl.Role = "root" }))},
+		{"bad subject", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(cl *claims) { cl.Subject = "alice" }))},
+		{"tampered payload", tamper(signToken(t, jwt.SigningMethodHS256, testSecret, validClaims()))},
+		{"garbage", "not-a-token"},
+	} {
+		if p, err := c.ParseToken(tc.token); err == nil {
+			t.Errorf("%s: accepted as %+v", tc.name, p)
+		}
+	}
+}
+
+// 把 payload 换成角色为 admin 的版本，签名不变
+func tamper(token string) string {
+	cl := validClaims()
+	cl.Role = models.RoleAdmin
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, cl).SigningString()
+	parts := strings.Split(token, ".")
+	parts[1] = strings.Split(forged, ".")[1]
+	return strings.Join(parts, ".")
+}
+
+func TestAPIKeys(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIKey(key) || !strings.HasPrefix(key, prefix) || len(prefix) != len(apiKeyPrefix)+8 {
+		t.Errorf("key %q, prefix %q", key, prefix)
+	}
+	if hash != HashAPIKey(key) || len(hash) != 64 {
+		t.Errorf("hash %q is not the hex SHA-256 of the key", hash)
+	}
+	other, _, otherHash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == key || otherHash == hash {
+		t.Error("generated the same key twice")
+	}
+	if IsAPIKey(strings.TrimPrefix(key, apiKeyPrefix)) {
+		t.Error("key without prefix treated as api key")
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	generated, _, _, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		key  string
+		ok   bool
+	}{
+		{"generated", generated, true},
+		{"hex 32 bytes", "hpc_" + "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", true},
+		{"no prefix", strings.TrimPrefix(generated, apiKeyPrefix), false},
+		{"prefix only", "hpc_", false},
+		{"short", "hpc_x", false},
+		{"31 characters", "hpc_" + generated[4:35], false},
+		{"repeated character", "hpc_" + strings.Repeat("a", 64), false},
+		{"few distinct characters", "hpc_" + strings.Repeat("abcd", 8), false},
+		{"invalid characters", "hpc_" + generated[4:30] + "+/=+/=+/=", false},
+	} {
+		if err := ValidateAPIKey(tc.key); (err == nil) != tc.ok {
+			t.Errorf("%s: ValidateAPIKey(%q) = %v", tc.name, tc.key, err)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+	"hpc-site/internal/auth"
+	"hpc-site/internal/crawlhttp"
+	"hpc-site/internal/relevance"
+	"hpc-site/internal/source"
+)
+
+// 服务的全部配置。加载顺序为 默认值 → 配置文件 → 环境变量 → 命令行参数，后者覆盖前者，
+// 加载完成后统一校验，任何一项无效都不启动
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	Auth     Auth     `yaml:"auth"`
+	Crawler  Crawler  `yaml:"crawler"`
+	Sources  Sources  `yaml:"sources"`
+}
+
+type Server struct {
+	Addr        string   `yaml:"addr"`         // 监听地址，如 :8080
+	CORSOrigins []string `yaml:"cors_origins"` // 允许跨域访问的 Origin，* 表示任意来源，为空时不处理跨域
+	LogLevel    string   `yaml:"log_level"`    // debug | info | warn | error
+	DemoMode    bool     `yaml:"demo_mode"`    // 使用内存存储，不连接数据库
+
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // 收到退出信号后等待请求处理完、抓取任务保存断点的最长时间
+}
+
+type Database struct {
+	URL          string        `yaml:"url"`
+	QueryTimeout time.Duration `yaml:"query_timeout"`  // 单次数据库调用的超时时间，0 表示不限制
+	AutoMigrate  bool          `yaml:"auto_migrate"`   // 启动时执行未应用的迁移
+	MaxOpenConns int           `yaml:"max_open_conns"` // 连接池最大连接数，0 表示不限制
+	MaxIdleConns int           `yaml:"max_idle_conns"` // 连接池保留的空闲连接数
+
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`  // 连接的最长使用时间，便于数据库切换或重启后重新建立连接，0 表示不限制
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"` // 空闲连接的最长保留时间，0 表示不限制
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`    // 启动时等待数据库可用的最长时间，期间按指数退避重试，0 表示只尝试一次
+}
+
+type Auth struct {
+	JWTSecret   string        `yaml:"jwt_secret"`    // HMAC-SHA256 签名密钥，至少 32 字节，为空时不签发也不接受 JWT
+	TokenTTL    time.Duration `yaml:"token_ttl"`     // 签发的 JWT 有效期
+	AdminAPIKey string        `yaml:"admin_api_key"` // 启动时为 admin 用户登记的 API key，hpc_ 前缀之后至少 32 个随机字符
+}
+
+type Crawler struct {
+	UserAgent      string             `yaml:"user_agent"`
+	Mailto         string             `yaml:"mailto"` // 联系邮箱，附加到 User-Agent，并用于 Crossref/OpenAlex 的 polite pool；必填
+	Timeout        time.Duration      `yaml:"timeout"`
+	MaxRetries     int                `yaml:"max_retries"`
+	RateLimit      float64            `yaml:"rate_limit"`       // 每个 host 每秒请求数
+	HostRateLimits map[string]float64 `yaml:"host_rate_limits"` // 按 host 覆盖 RateLimit
+	RespectRobots  bool               `yaml:"respect_robots"`
+	Concurrency    int                `yaml:"concurrency"`    // 并发抓取论文详情的 worker 数
+	MinConfidence  float64            `yaml:"min_confidence"` // 论文—软件关联直接发布的最低置信度
+	Schedule       string             `yaml:"schedule"`       // 定时抓取的 cron 表达式，为空时不启用
+}
+
+type Sources struct {
+	Enabled             []string `yaml:"enabled"`                // 启用的论文来源，按顺序检索
+	ArxivBackend        string   `yaml:"arxiv_backend"`          // api 使用 Atom 导出 API，html 解析网页
+	ArxivAPIURL         string   `yaml:"arxiv_api_url"`          // 可指向本地 fixture 服务
+	ArxivWebURL         string   `yaml:"arxiv_web_url"`          // html 后端抓取搜索页和详情页的地址
+	ArxivSearchPageSize int      `yaml:"arxiv_search_page_size"` // html 后端每页的结果数
+	CrossrefURL         string   `yaml:"crossref_url"`
+	OpenAlexURL         string   `yaml:"openalex_url"`
+}
+
+// HMAC-SHA256 签名密钥的最短长度，与摘要长度相同
+const minJWTSecretLen = 32
+
+var (
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	knownSources   = []string{"arxiv", "crossref", "openalex"}
+	arxivBackends  = []string{"api", "html"}
+	arxivPageSizes = []int{25, 50, 100, 200} // arXiv 搜索页只接受这几种 size
+)
+
+func Default() Config {
+	crawl := crawlhttp.DefaultConfig()
+	return Config{
+		Server: Server{
+			Addr:     ":8080",
+			LogLevel: "info",
+
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: Database{
+			QueryTimeout: 10 * time.Second,
+			AutoMigrate:  true,
+			MaxOpenConns: 25,
+			MaxIdleConns: 5,
+
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+		},
+		Auth: Auth{
+			TokenTTL: time.Hour,
+		},
+		Crawler: Crawler{
+			UserAgent:      crawl.UserAgent,
+			Timeout:        crawl.Timeout,
+			MaxRetries:     crawl.MaxRetries,
+			RateLimit:      crawl.RateLimit,
+			HostRateLimits: crawl.HostRateLimits,
+			RespectRobots:  crawl.RespectRobots,
+			Concurrency:    4,
+			MinConfidence:  relevance.DefaultThreshold,
+		},
+		Sources: Sources{
+			Enabled:             []string{"arxiv"},
+			ArxivBackend:        "api",
+			ArxivAPIURL:         source.DefaultArxivAPIURL,
+			ArxivWebURL:         "https://arxiv.org",
+			ArxivSearchPageSize: 50,
+			CrossrefURL:         source.DefaultCrossrefURL,
+			OpenAlexURL:         source.DefaultOpenAlexURL,
+		},
+	}
+}
+
+// 按 默认值 → 配置文件（-config 或 CONFIG_FILE）→ 环境变量 → 命令行参数 的顺序加载并校验配置，
+// 返回 flag 之后的剩余参数（如 migrate 子命令）。-h 时返回 flag.ErrHelp
+func Load(args []string) (*Config, []string, error) {
+	path := os.Getenv("CONFIG_FILE")
+
+	// 先解析一遍参数拿到 -config，其余参数要等文件和环境变量加载完再覆盖
+	scratch := Default()
+	if err := newFlagSet(&scratch, &path).Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Default()
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := loadEnv(&cfg); err != nil {
+		return nil, nil, err
+	}
+	fs := newFlagSet(&cfg, &path)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, fs.Args(), nil
+}
+
+func newFlagSet(cfg *Config, path *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("hpc-site", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: hpc-site [flags] [migrate up | down [n] | status | to <version>]")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(path, "config", *path, "YAML 配置文件路径（CONFIG_FILE）")
+	fs.StringVar(&cfg.Server.Addr, "addr", cfg.Server.Addr, "监听地址（SERVER_ADDR）")
+	fs.StringVar(&cfg.Server.LogLevel, "log-level", cfg.Server.LogLevel, "日志级别 debug|info|warn|error（LOG_LEVEL）")
+	fs.DurationVar(&cfg.Server.ShutdownTimeout, "shutdown-timeout", cfg.Server.ShutdownTimeout, "退出时等待请求和抓取任务的最长时间（SHUTDOWN_TIMEOUT）")
+	fs.BoolVar(&cfg.Server.DemoMode, "demo", cfg.Server.DemoMode, "演示模式，数据保存在内存中（DEMO_MODE）")
+	fs.Func("cors-origins", "逗号分隔的跨域来源（CORS_ALLOWED_ORIGINS）", func(v string) error {
+		cfg.Server.CORSOrigins = splitList(v)
+		return nil
+	})
+	fs.IntVar(&cfg.Database.MaxOpenConns, "db-max-open-conns", cfg.Database.MaxOpenConns, "数据库最大连接数（DB_MAX_OPEN_CONNS）")
+	fs.IntVar(&cfg.Database.MaxIdleConns, "db-max-idle-conns", cfg.Database.MaxIdleConns, "数据库空闲连接数（DB_MAX_IDLE_CONNS）")
+	fs.IntVar(&cfg.Crawler.Concurrency, "crawler-concurrency", cfg.Crawler.Concurrency, "并发抓取论文的 worker 数（CRAWLER_CONCURRENCY）")
+	fs.Float64Var(&cfg.Crawler.RateLimit, "crawler-rate-limit", cfg.Crawler.RateLimit, "每个 host 每秒请求数（CRAWLER_RATE_LIMIT）")
+	fs.StringVar(&cfg.Crawler.Schedule, "crawl-schedule", cfg.Crawler.Schedule, "定时抓取的 cron 表达式（CRAWL_SCHEDULE）")
+	return fs
+}
+
+// 读取 YAML 配置文件（JSON 也是合法的 YAML），未知字段视为错误以免拼错的配置被静默忽略
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("解析配置文件 %s: %w", path, err)
+	}
+	return nil
+}
+
+// 校验全部配置项，返回所有无效项
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr %q 无效: %v", c.Server.Addr, err))
+	}
+	check(slices.Contains(logLevels, c.Server.LogLevel), "server.log_level %q 无效，可选 %s", c.Server.LogLevel, strings.Join(logLevels, "|"))
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout 必须大于 0")
+	for _, o := range c.Server.CORSOrigins {
+		check(o == "*" || validOrigin(o), "server.cors_origins 中的 %q 无效，应为 * 或 scheme://host[:port]", o)
+	}
+
+	check(c.Server.DemoMode || c.Database.URL != "", "database.url 未设置（DATABASE_URL 或 DATABASE_URL_FILE）")
+	check(c.Database.QueryTimeout >= 0, "database.query_timeout 不能为负数")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns 不能为负数")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns 不能为负数")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime 不能为负数")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time 不能为负数")
+	check(c.Database.ConnectTimeout >= 0, "database.connect_timeout 不能为负数")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns (%d) 不能大于 max_open_conns (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+
+	check(c.Auth.TokenTTL > 0, "auth.token_ttl 必须大于 0")
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= minJWTSecretLen,
+		"auth.jwt_secret（JWT_SECRET）只有 %d 字节，至少需要 %d 字节", len(c.Auth.JWTSecret), minJWTSecretLen)
+	if c.Auth.AdminAPIKey != "" {
+		if err := auth.ValidateAPIKey(c.Auth.AdminAPIKey); err != nil {
+			errs = append(errs, fmt.Errorf("auth.admin_api_key（ADMIN_API_KEY）无效: %v", err))
+		}
+	}
+
+	check(c.Crawler.UserAgent != "", "crawler.user_agent 不能为空")
+	// 抓取请求的 User-Agent 必须带联系方式，演示模式同样会抓取
+	check(c.Crawler.Mailto != "", "crawler.mailto 未设置（CRAWLER_MAILTO），抓取请求需要带上联系邮箱")
+	check(c.Crawler.Mailto == "" || validMailto(c.Crawler.Mailto), "crawler.mailto %q 不是有效的邮箱地址", c.Crawler.Mailto)
+	check(c.Crawler.Timeout > 0, "crawler.timeout 必须大于 0")
+	check(c.Crawler.MaxRetries >= 0, "crawler.max_retries 不能为负数")
+	check(c.Crawler.RateLimit >= 0, "crawler.rate_limit 不能为负数")
+	for host, r := range c.Crawler.HostRateLimits {
+		check(host != "" && r >= 0, "crawler.host_rate_limits 中的 %s=%v 无效", host, r)
+	}
+	check(c.Crawler.Concurrency > 0, "crawler.concurrency 必须大于 0")
+	check(c.Crawler.MinConfidence >= 0 && c.Crawler.MinConfidence <= 1, "crawler.min_confidence 应在 0 到 1 之间")
+	if c.Crawler.Schedule != "" {
+		if _, err := cron.ParseStandard(c.Crawler.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("crawler.schedule %q 无效: %v", c.Crawler.Schedule, err))
+		}
+	}
+
+	check(len(c.Sources.Enabled) > 0, "sources.enabled 不能为空")
+	for _, name := range c.Sources.Enabled {
+		check(slices.Contains(knownSources, name), "sources.enabled 中的 %q 未知，可选 %s", name, strings.Join(knownSources, "|"))
+	}
+	check(slices.Contains(arxivBackends, c.Sources.ArxivBackend), "sources.arxiv_backend %q 无效，可选 %s", c.Sources.ArxivBackend, strings.Join(arxivBackends, "|"))
+	check(slices.Contains(arxivPageSizes, c.Sources.ArxivSearchPageSize), "sources.arxiv_search_page_size %d 无效，可选 25|50|100|200", c.Sources.ArxivSearchPageSize)
+	for name, u := range map[string]string{
+		"arxiv_api_url": c.Sources.ArxivAPIURL,
+		"arxiv_web_url": c.Sources.ArxivWebURL,
+		"crossref_url":  c.Sources.CrossrefURL,
+		"openalex_url":  c.Sources.OpenAlexURL,
+	} {
+		check(validBaseURL(u), "sources.%s %q 不是有效的 http(s) 地址", name, u)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validMailto(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Name == "" && addr.Address == s
+}
+
+func validBaseURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// 浏览器发送的 Origin 只有 scheme、host 和端口
+func validOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" && u.RawQuery == "" && u.User == nil
+}
+
+// 逗号分隔的列表，去掉空白和空项
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
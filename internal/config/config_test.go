@@ -0,0 +1,261 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 清空会影响 Load 的环境变量，空值与未设置等价
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"CONFIG_FILE", "SERVER_ADDR", "CORS_ALLOWED_ORIGINS", "LOG_LEVEL", "DEMO_MODE", "SHUTDOWN_TIMEOUT",
+		"DATABASE_URL", "DATABASE_URL_FILE", "DB_QUERY_TIMEOUT", "DB_AUTO_MIGRATE", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS",
+		"DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME", "DB_CONNECT_TIMEOUT",
+		"JWT_SECRET", "JWT_SECRET_FILE", "JWT_TTL", "ADMIN_API_KEY", "ADMIN_API_KEY_FILE",
+		"CRAWLER_USER_AGENT", "CRAWLER_MAILTO", "CRAWLER_TIMEOUT", "CRAWLER_MAX_RETRIES", "CRAWLER_RATE_LIMIT",
+		"CRAWLER_HOST_RATE_LIMITS", "CRAWLER_RESPECT_ROBOTS", "CRAWLER_CONCURRENCY", "PAPER_MIN_CONFIDENCE", "CRAWL_SCHEDULE",
+		"PAPER_SOURCES", "ARXIV_BACKEND", "ARXIV_API_URL", "ARXIV_WEB_URL", "ARXIV_SEARCH_PAGE_SIZE",
+		"CROSSREF_API_URL", "OPENALEX_API_URL",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+// 只设置必填项的环境
+func requiredEnv(t *testing.T) {
+	t.Helper()
+	clearEnv(t)
+	t.Setenv("DATABASE_URL", "postgres://localhost/hpc")
+	t.Setenv("CRAWLER_MAILTO", "crawler@example.org")
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRequiresDatabaseAndMailto(t *testing.T) {
+	clearEnv(t)
+	_, _, err := Load(nil)
+	if err == nil {
+		t.Fatal("loaded without DATABASE_URL and CRAWLER_MAILTO")
+	}
+	for _, want := range []string{"database.url", "crawler.mailto"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	// 演示模式不需要数据库
+	t.Setenv("DEMO_MODE", "true")
+	t.Setenv("CRAWLER_MAILTO", "crawler@example.org")
+	if _, _, err := Load(nil); err != nil {
+		t.Errorf("demo mode: %v", err)
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	requiredEnv(t)
+	t.Setenv("SERVER_ADDR", "127.0.0.1:9090")
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://a.example.org , ,http://localhost:3000")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("DB_QUERY_TIMEOUT", "5s")
+	t.Setenv("DB_AUTO_MIGRATE", "false")
+	t.Setenv("JWT_TTL", "15m")
+	t.Setenv("CRAWLER_RATE_LIMIT", "0.5")
+	t.Setenv("CRAWLER_HOST_RATE_LIMITS", "api.crossref.org=5, example.org = 0")
+	t.Setenv("CRAWLER_CONCURRENCY", " 8 ")
+	t.Setenv("PAPER_SOURCES", "ArXiv,OpenAlex")
+	t.Setenv("ARXIV_BACKEND", "HTML")
+
+	cfg, args, err := Load([]string{"migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(args, []string{"migrate", "up"}) {
+		t.Errorf("args = %q", args)
+	}
+	if cfg.Server.Addr != "127.0.0.1:9090" || cfg.Server.LogLevel != "debug" {
+		t.Errorf("server = %+v", cfg.Server)
+	}
+	if !slices.Equal(cfg.Server.CORSOrigins, []string{"https://a.example.org", "http://localhost:3000"}) {
+		t.Errorf("cors origins = %q", cfg.Server.CORSOrigins)
+	}
+	if cfg.Database.URL != "postgres://localhost/hpc" || cfg.Database.QueryTimeout != 5*time.Second || cfg.Database.AutoMigrate {
+		t.Errorf("database = %+v", cfg.Database)
+	}
+	if cfg.Auth.TokenTTL != 15*time.Minute {
+		t.Errorf("token ttl = %s", cfg.Auth.TokenTTL)
+	}
+	if cfg.Crawler.RateLimit != 0.5 || cfg.Crawler.Concurrency != 8 {
+		t.Errorf("crawler = %+v", cfg.Crawler)
+	}
+	// 按 host 的限速合并到默认值上
+	if r := cfg.Crawler.HostRateLimits; r["api.crossref.org"] != 5 || r["example.org"] != 0 || len(r) < 3 {
+		t.Errorf("host rate limits = %v", r)
+	}
+	if !slices.Equal(cfg.Sources.Enabled, []string{"arxiv", "openalex"}) || cfg.Sources.ArxivBackend != "html" {
+		t.Errorf("sources = %+v", cfg.Sources)
+	}
+	// 默认值保持不变
+	if cfg.Server.ShutdownTimeout != Default().Server.ShutdownTimeout {
+		t.Errorf("shutdown timeout = %s", cfg.Server.ShutdownTimeout)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	requiredEnv(t)
+	path := writeFile(t, "config.yaml", "server:\n  addr: \":7000\"\n  log_level: warn\ncrawler:\n  concurrency: 2\n  rate_limit: 3\n")
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("CRAWLER_CONCURRENCY", "6")
+
+	// 配置文件 < 环境变量 < 命令行参数
+	cfg, _, err := Load([]string{"-crawler-concurrency", "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Addr != ":7000" || cfg.Crawler.RateLimit != 3 {
+		t.Errorf("file values not applied: addr %q, rate limit %v", cfg.Server.Addr, cfg.Crawler.RateLimit)
+	}
+	if cfg.Server.LogLevel != "error" {
+		t.Errorf("log level = %q, env should override file", cfg.Server.LogLevel)
+	}
+	if cfg.Crawler.Concurrency != 10 {
+		t.Errorf("concurrency = %d, flag should override env", cfg.Crawler.Concurrency)
+	}
+
+	// -config 覆盖 CONFIG_FILE
+	other := writeFile(t, "other.yaml", "server:\n  addr: \":7100\"\n")
+	if cfg, _, err := Load([]string{"-config", other}); err != nil || cfg.Server.Addr != ":7100" {
+		t.Errorf("-config: %+v, %v", cfg, err)
+	}
+
+	// 拼错的字段不会被静默忽略
+	t.Setenv("CONFIG_FILE", writeFile(t, "typo.yaml", "server:\n  adr: \":7000\"\n"))
+	if _, _, err := Load(nil); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
+
+func TestLoadInvalidEnv(t *testing.T) {
+	requiredEnv(t)
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	t.Setenv("CRAWLER_TIMEOUT", "10")
+	t.Setenv("DEMO_MODE", "maybe")
+	t.Setenv("CRAWLER_HOST_RATE_LIMITS", "example.org")
+
+	_, _, err := Load(nil)
+	if err == nil {
+		t.Fatal("invalid environment accepted")
+	}
+	for _, name := range []string{"DB_MAX_OPEN_CONNS", "CRAWLER_TIMEOUT", "DEMO_MODE", "CRAWLER_HOST_RATE_LIMITS"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %s", err, name)
+		}
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	requiredEnv(t)
+	secret := "0123456789abcdef0123456789abcdef"
+	t.Setenv("DATABASE_URL_FILE", writeFile(t, "database_url", "postgres://db/from-file\n"))
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt_secret", secret+"\r\n"))
+	t.Setenv("JWT_SECRET", "ignored-because-the-file-takes-precedence")
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 文件优先于同名变量，末尾换行被去掉
+	if cfg.Database.URL != "postgres://db/from-file" {
+		t.Errorf("database url = %q", cfg.Database.URL)
+	}
+	if cfg.Auth.JWTSecret != secret {
+		t.Errorf("jwt secret = %q", cfg.Auth.JWTSecret)
+	}
+
+	// 只去掉末尾的换行，不影响其他空白
+	t.Setenv("DATABASE_URL_FILE", writeFile(t, "database_url", "postgres://db/x \n\n"))
+	if cfg, _, err := Load(nil); err != nil {
+		t.Error(err)
+	} else if cfg.Database.URL != "postgres://db/x " {
+		t.Errorf("database url = %q", cfg.Database.URL)
+	}
+
+	t.Setenv("ADMIN_API_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "ADMIN_API_KEY_FILE") {
+		t.Errorf("missing secret file: %v", err)
+	}
+}
+
+// 只改动一项的有效配置
+func validConfig(modify func(*Config)) Config {
+	cfg := Default()
+	cfg.Database.URL = "postgres://localhost/hpc"
+	cfg.Crawler.Mailto = "crawler@example.org"
+	modify(&cfg)
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if cfg := validConfig(func(*Config) {}); cfg.Validate() != nil {
+		t.Fatalf("default config invalid: %v", cfg.Validate())
+	}
+
+	for _, tc := range []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"addr without port", func(c *Config) { c.Server.Addr = "localhost" }, "server.addr"},
+		{"log level", func(c *Config) { c.Server.LogLevel = "verbose" }, "server.log_level"},
+		{"zero shutdown timeout", func(c *Config) { c.Server.ShutdownTimeout = 0 }, "server.shutdown_timeout"},
+		{"negative shutdown timeout", func(c *Config) { c.Server.ShutdownTimeout = -time.Second }, "server.shutdown_timeout"},
+		{"cors origin with path", func(c *Config) { c.Server.CORSOrigins = []string{"https://a.example.org/app"} }, "server.cors_origins"},
+		{"no database url", func(c *Config) { c.Database.URL = "" }, "database.url"},
+		{"negative query timeout", func(c *Config) { c.Database.QueryTimeout = -time.Second }, "database.query_timeout"},
+		{"negative connect timeout", func(c *Config) { c.Database.ConnectTimeout = -time.Second }, "database.connect_timeout"},
+		{"idle above open conns", func(c *Config) { c.Database.MaxOpenConns, c.Database.MaxIdleConns = 2, 5 }, "database.max_idle_conns"},
+		{"zero token ttl", func(c *Config) { c.Auth.TokenTTL = 0 }, "auth.token_ttl"},
+		{"short jwt secret", func(c *Config) { c.Auth.JWTSecret = "too-short" }, "auth.jwt_secret"},
+		{"jwt secret one byte short", func(c *Config) { c.Auth.JWTSecret = strings.Repeat("x", 31) }, "auth.jwt_secret"},
+		{"weak admin api key", func(c *Config) { c.Auth.AdminAPIKey = "hpc_admin" }, "auth.admin_api_key"},
+		{"no mailto", func(c *Config) { c.Crawler.Mailto = "" }, "crawler.mailto"},
+		{"mailto without domain", func(c *Config) { c.Crawler.Mailto = "crawler" }, "crawler.mailto"},
+		{"mailto with name", func(c *Config) { c.Crawler.Mailto = "Crawler <crawler@example.org>" }, "crawler.mailto"},
+		{"zero crawler timeout", func(c *Config) { c.Crawler.Timeout = 0 }, "crawler.timeout"},
+		{"negative crawler timeout", func(c *Config) { c.Crawler.Timeout = -time.Second }, "crawler.timeout"},
+		{"negative rate limit", func(c *Config) { c.Crawler.RateLimit = -1 }, "crawler.rate_limit"},
+		{"negative host rate limit", func(c *Config) { c.Crawler.HostRateLimits = map[string]float64{"example.org": -1} }, "crawler.host_rate_limits"},
+		{"zero concurrency", func(c *Config) { c.Crawler.Concurrency = 0 }, "crawler.concurrency"},
+		{"confidence above one", func(c *Config) { c.Crawler.MinConfidence = 1.5 }, "crawler.min_confidence"},
+		{"bad schedule", func(c *Config) { c.Crawler.Schedule = "every day" }, "crawler.schedule"},
+		{"no sources", func(c *Config) { c.Sources.Enabled = nil }, "sources.enabled"},
+		{"unknown source", func(c *Config) { c.Sources.Enabled = []string{"scholar"} }, "sources.enabled"},
+		{"arxiv backend", func(c *Config) { c.Sources.ArxivBackend = "rss" }, "sources.arxiv_backend"},
+		{"arxiv page size", func(c *Config) { c.Sources.ArxivSearchPageSize = 30 }, "sources.arxiv_search_page_size"},
+		{"source url scheme", func(c *Config) { c.Sources.CrossrefURL = "ftp://api.crossref.org" }, "sources.crossref_url"},
+	} {
+		cfg := validConfig(tc.modify)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: Validate() = %v, want error about %s", tc.name, err, tc.want)
+		}
+	}
+
+	// 所有无效项一并返回
+	cfg := validConfig(func(c *Config) { c.Crawler.Timeout, c.Auth.JWTSecret = 0, "short" })
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "crawler.timeout") || !strings.Contains(err.Error(), "auth.jwt_secret") {
+		t.Errorf("Validate() = %v, want both errors", err)
+	}
+}
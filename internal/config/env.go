@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 用环境变量覆盖配置，未设置或为空的变量不覆盖。
+// 密钥类变量（DATABASE_URL、JWT_SECRET、ADMIN_API_KEY）也可以通过 <NAME>_FILE 指定文件，
+// 如 Docker/Kubernetes secret 挂载的文件，避免把明文写进镜像或环境变量；两者都设置时以文件为准
+//
+//	CONFIG_FILE                      配置文件路径
+//	SERVER_ADDR                      监听地址
+//	CORS_ALLOWED_ORIGINS             逗号分隔的跨域来源
+//	LOG_LEVEL                        debug | info | warn | error
+//	DEMO_MODE                        演示模式
+//	SHUTDOWN_TIMEOUT                 退出时等待请求和抓取任务的最长时间，如 30s
+//	DATABASE_URL(_FILE)              PostgreSQL 连接串
+//	DB_QUERY_TIMEOUT                 单次数据库调用超时，如 5s
+//	DB_AUTO_MIGRATE                  启动时执行迁移
+//	DB_MAX_OPEN_CONNS                连接池最大连接数
+//	DB_MAX_IDLE_CONNS                连接池空闲连接数
+//	DB_CONN_MAX_LIFETIME             连接的最长使用时间，如 30m
+//	DB_CONN_MAX_IDLE_TIME            空闲连接的最长保留时间
+//	DB_CONNECT_TIMEOUT               启动时等待数据库可用的最长时间
+//	JWT_SECRET(_FILE)                JWT 签名密钥，至少 32 字节
+//	JWT_TTL                          JWT 有效期
+//	ADMIN_API_KEY(_FILE)             启动时登记的管理员 API key，hpc_ 之后至少 32 个随机字符
+//	CRAWLER_USER_AGENT               抓取用的 User-Agent
+//	CRAWLER_MAILTO                   联系邮箱，必填
+//	CRAWLER_TIMEOUT                  单次请求超时
+//	CRAWLER_MAX_RETRIES              最大重试次数
+//	CRAWLER_RATE_LIMIT               每个 host 每秒请求数
+//	CRAWLER_HOST_RATE_LIMITS         按 host 覆盖，如 export.arxiv.org=0.33,api.crossref.org=5
+//	CRAWLER_RESPECT_ROBOTS           遵守 robots.txt 的 Crawl-delay
+//	CRAWLER_CONCURRENCY              并发抓取论文的 worker 数
+//	PAPER_MIN_CONFIDENCE             论文—软件关联直接发布的最低置信度
+//	CRAWL_SCHEDULE                   定时抓取的 cron 表达式
+//	PAPER_SOURCES                    逗号分隔的论文来源
+//	ARXIV_BACKEND                    api | html
+//	ARXIV_API_URL                    arXiv 导出 API 地址
+//	ARXIV_WEB_URL                    arXiv 网站地址
+//	ARXIV_SEARCH_PAGE_SIZE           arXiv 搜索页每页结果数
+//	CROSSREF_API_URL                 Crossref API 地址
+//	OPENALEX_API_URL                 OpenAlex API 地址
+func loadEnv(cfg *Config) error {
+	var e envLoader
+
+	e.str("SERVER_ADDR", &cfg.Server.Addr)
+	e.list("CORS_ALLOWED_ORIGINS", &cfg.Server.CORSOrigins)
+	e.str("LOG_LEVEL", &cfg.Server.LogLevel)
+	e.bool("DEMO_MODE", &cfg.Server.DemoMode)
+	e.duration("SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	e.secret("DATABASE_URL", &cfg.Database.URL)
+	e.duration("DB_QUERY_TIMEOUT", &cfg.Database.QueryTimeout)
+	e.bool("DB_AUTO_MIGRATE", &cfg.Database.AutoMigrate)
+	e.int("DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &cfg.Database.ConnMaxIdleTime)
+	e.duration("DB_CONNECT_TIMEOUT", &cfg.Database.ConnectTimeout)
+
+	e.secret("JWT_SECRET", &cfg.Auth.JWTSecret)
+	e.duration("JWT_TTL", &cfg.Auth.TokenTTL)
+	e.secret("ADMIN_API_KEY", &cfg.Auth.AdminAPIKey)
+
+	e.str("CRAWLER_USER_AGENT", &cfg.Crawler.UserAgent)
+	e.str("CRAWLER_MAILTO", &cfg.Crawler.Mailto)
+	e.duration("CRAWLER_TIMEOUT", &cfg.Crawler.Timeout)
+	e.int("CRAWLER_MAX_RETRIES", &cfg.Crawler.MaxRetries)
+	e.float("CRAWLER_RATE_LIMIT", &cfg.Crawler.RateLimit)
+	e.rates("CRAWLER_HOST_RATE_LIMITS", &cfg.Crawler.HostRateLimits)
+	e.bool("CRAWLER_RESPECT_ROBOTS", &cfg.Crawler.RespectRobots)
+	e.int("CRAWLER_CONCURRENCY", &cfg.Crawler.Concurrency)
+	e.float("PAPER_MIN_CONFIDENCE", &cfg.Crawler.MinConfidence)
+	e.str("CRAWL_SCHEDULE", &cfg.Crawler.Schedule)
+
+	e.list("PAPER_SOURCES", &cfg.Sources.Enabled)
+	e.str("ARXIV_BACKEND", &cfg.Sources.ArxivBackend)
+	e.str("ARXIV_API_URL", &cfg.Sources.ArxivAPIURL)
+	e.str("ARXIV_WEB_URL", &cfg.Sources.ArxivWebURL)
+	e.int("ARXIV_SEARCH_PAGE_SIZE", &cfg.Sources.ArxivSearchPageSize)
+	e.str("CROSSREF_API_URL", &cfg.Sources.CrossrefURL)
+	e.str("OPENALEX_API_URL", &cfg.Sources.OpenAlexURL)
+
+	// 来源名和后端不区分大小写
+	for i, name := range cfg.Sources.Enabled {
+		cfg.Sources.Enabled[i] = strings.ToLower(name)
+	}
+	cfg.Sources.ArxivBackend = strings.ToLower(cfg.Sources.ArxivBackend)
+
+	return errors.Join(e.errs...)
+}
+
+// 逐个读取环境变量，格式错误的变量记录下来一并返回
+type envLoader struct {
+	errs []error
+}
+
+func (e *envLoader) lookup(name string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(name))
+	return v, v != ""
+}
+
+func (e *envLoader) fail(name, v string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("环境变量 %s=%q 无效: %v", name, v, err))
+}
+
+func (e *envLoader) str(name string, dst *string) {
+	if v, ok := e.lookup(name); ok {
+		*dst = v
+	}
+}
+
+// 同时支持 NAME 和 NAME_FILE，两者都设置时以文件为准；文件末尾的换行会被去掉
+func (e *envLoader) secret(name string, dst *string) {
+	v, ok := e.lookup(name)
+	path, fromFile := e.lookup(name + "_FILE")
+	switch {
+	case fromFile:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("读取 %s_FILE: %w", name, err))
+			return
+		}
+		*dst = strings.TrimRight(string(b), "\r\n")
+	case ok:
+		*dst = v
+	}
+}
+
+func (e *envLoader) bool(name string, dst *bool) {
+	if v, ok := e.lookup(name); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.fail(name, v, err)
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e *envLoader) int(name string, dst *int) {
+	if v, ok := e.lookup(name); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.fail(name, v, err)
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envLoader) float(name string, dst *float64) {
+	if v, ok := e.lookup(name); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.fail(name, v, err)
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envLoader) duration(name string, dst *time.Duration) {
+	if v, ok := e.lookup(name); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.fail(name, v, err)
+			return
+		}
+		*dst = d
+	}
+}
+
+func (e *envLoader) list(name string, dst *[]string) {
+	if v, ok := e.lookup(name); ok {
+		*dst = splitList(v)
+	}
+}
+
+// host=rate 列表，合并到已有的按 host 限速上
+func (e *envLoader) rates(name string, dst *map[string]float64) {
+	v, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+	rates := make(map[string]float64, len(*dst))
+	for host, r := range *dst {
+		rates[host] = r
+	}
+	for _, pair := range splitList(v) {
+		host, limit, ok := strings.Cut(pair, "=")
+		r, err := strconv.ParseFloat(strings.TrimSpace(limit), 64)
+		if !ok || err != nil {
+			e.fail(name, pair, fmt.Errorf("应为 host=每秒请求数"))
+			continue
+		}
+		rates[strings.TrimSpace(host)] = r
+	}
+	*dst = rates
+}
@@ -0,0 +1,243 @@
+package crawlhttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"hpc-site/internal/metrics"
+)
+
+// 抓取用的 HTTP 客户端配置
+type Config struct {
+	UserAgent      string             // 需包含联系方式，便于对方站点联系我们；服务中由 crawler.mailto 附加
+	Timeout        time.Duration      // 单次请求超时（含读取响应体）
+	MaxRetries     int                // 429/503/网络错误的最大重试次数
+	BaseBackoff    time.Duration      // 指数退避的初始间隔
+	MaxBackoff     time.Duration      // 单次退避的上限，Retry-After 超过它时不再重试
+	RateLimit      float64            // 默认每个 host 每秒请求数
+	Burst          int                // 令牌桶容量
+	HostRateLimits map[string]float64 // 按 host 覆盖 RateLimit
+	RespectRobots  bool               // 读取 robots.txt 中的 Crawl-delay
+}
+
+// 默认配置：每个 host 每秒 1 次，arXiv 按其 API 说明每 3 秒 1 次
+func DefaultConfig() Config {
+	return Config{
+		UserAgent:   "hpc-site-crawler/1.0",
+		Timeout:     60 * time.Second,
+		MaxRetries:  5,
+		BaseBackoff: 2 * time.Second,
+		MaxBackoff:  2 * time.Minute,
+		RateLimit:   1,
+		Burst:       1,
+		HostRateLimits: map[string]float64{
+			"arxiv.org":        1.0 / 3,
+			"export.arxiv.org": 1.0 / 3,
+		},
+		RespectRobots: true,
+	}
+}
+
+// 所有抓取请求共用的客户端：按 host 限速、429/503 指数退避重试、统一 User-Agent
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	robots   map[string]*robotsRules
+}
+
+func New(cfg Config) *Client {
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+	return &Client{
+		cfg:      cfg,
+		http:     &http.Client{},
+		limiters: make(map[string]*rate.Limiter),
+		robots:   make(map[string]*robotsRules),
+	}
+}
+
+// 是否需要重试该响应状态
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// 发送请求；调用方负责关闭响应体。请求不能带 body（抓取只用 GET）
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Hostname()
+
+	if c.cfg.RespectRobots {
+		c.loadRobots(ctx, req.URL)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter(host).Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.once(req)
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(resp.StatusCode)
+		}
+		metrics.CrawlerResponses.WithLabelValues(host, code).Inc()
+		if err == nil && !retryable(resp.StatusCode) {
+			if resp.StatusCode == http.StatusOK {
+				metrics.CrawlerPagesFetched.WithLabelValues(host).Inc()
+			}
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if attempt >= c.cfg.MaxRetries {
+			if err != nil {
+				return nil, fmt.Errorf("%s: giving up after %d attempts: %w", req.URL, attempt+1, err)
+			}
+			return resp, nil // 交给调用方处理最终的 429/503
+		}
+
+		wait := c.backoff(attempt)
+		if err != nil {
+			log.Printf("⚠️ 请求 %s 失败（第 %d 次）: %v，%s 后重试", req.URL, attempt+1, err, wait)
+		} else {
+			if ra, ok := retryAfter(resp.Header.Get("Retry-After")); ok && ra > wait {
+				// 对方要求等待的时间超过退避上限时不再重试，以免 worker 被一个站点占住几小时
+				if ra > c.cfg.MaxBackoff {
+					log.Printf("⚠️ 请求 %s 返回 %d，Retry-After %s 超过退避上限 %s，不再重试", req.URL, resp.StatusCode, ra, c.cfg.MaxBackoff)
+					return resp, nil
+				}
+				wait = ra
+			}
+			log.Printf("⚠️ 请求 %s 返回 %d（第 %d 次），%s 后重试", req.URL, resp.StatusCode, attempt+1, wait)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		metrics.CrawlerRetries.WithLabelValues(host, code).Inc()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// GET 便捷方法
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// 单次请求，超时覆盖到响应体读取完毕
+func (c *Client) once(req *http.Request) (*http.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if c.cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), c.cfg.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+	r := req.Clone(ctx)
+	if c.cfg.UserAgent != "" {
+		r.Header.Set("User-Agent", c.cfg.UserAgent)
+	}
+
+	resp, err := c.http.Do(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// 带抖动的指数退避：d = min(max, base*2^attempt)，实际等待 [d/2, d)
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.cfg.BaseBackoff << attempt
+	if d <= 0 || d > c.cfg.MaxBackoff {
+		d = c.cfg.MaxBackoff
+	}
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(d-half)))
+}
+
+// 解析 Retry-After：秒数或 HTTP 日期
+func retryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func (c *Client) limiter(host string) *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.limiters[host]; ok {
+		return l
+	}
+	r, ok := c.cfg.HostRateLimits[host]
+	if !ok {
+		r = c.cfg.RateLimit
+	}
+	limit := rate.Inf
+	if r > 0 {
+		limit = rate.Limit(r)
+	}
+	l := rate.NewLimiter(limit, c.cfg.Burst)
+	c.limiters[host] = l
+	return l
+}
+
+// robots.txt 要求的 Crawl-delay 比当前限速更慢时，下调该 host 的速率
+func (c *Client) applyCrawlDelay(host string, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	l := c.limiter(host)
+	if limit := rate.Every(delay); limit < l.Limit() {
+		log.Printf("%s 的 robots.txt 要求 Crawl-delay %s", host, delay)
+		l.SetLimit(limit)
+		l.SetBurst(1)
+	}
+}
@@ -0,0 +1,212 @@
+package crawlhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 依次返回 statuses 中的状态码（用完后返回 200），记录每次请求的时间和 User-Agent
+type flakyServer struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	requests   []time.Time
+	agents     []string
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, time.Now())
+	s.agents = append(s.agents, r.UserAgent())
+	if len(s.statuses) > 0 {
+		status := s.statuses[0]
+		s.statuses = s.statuses[1:]
+		if s.retryAfter != "" {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		w.WriteHeader(status)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+func (s *flakyServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// 两次请求之间的间隔
+func (s *flakyServer) gap(i int) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[i].Sub(s.requests[i-1])
+}
+
+// 不限速、不读 robots.txt、退避很短的配置
+func testConfig() Config {
+	return Config{
+		UserAgent:   "hpc-site-crawler/1.0 (mailto:crawler@example.org)",
+		Timeout:     5 * time.Second,
+		MaxRetries:  3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+		Burst:       1,
+	}
+}
+
+func get(t *testing.T, c *Client, url string) (int, time.Duration) {
+	t.Helper()
+	start := time.Now()
+	resp, err := c.Get(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, time.Since(start)
+}
+
+func TestRetriesTooManyRequestsAndUnavailable(t *testing.T) {
+	srv := &flakyServer{statuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	status, _ := get(t, New(testConfig()), ts.URL)
+	if status != http.StatusOK || srv.count() != 3 {
+		t.Errorf("status %d after %d requests, want 200 after 3", status, srv.count())
+	}
+	if ua := srv.agents[0]; !strings.Contains(ua, "mailto:crawler@example.org") {
+		t.Errorf("User-Agent = %q", ua)
+	}
+
+	// 其他错误状态不重试
+	srv = &flakyServer{statuses: []int{http.StatusInternalServerError}}
+	ts2 := httptest.NewServer(srv)
+	defer ts2.Close()
+	if status, _ := get(t, New(testConfig()), ts2.URL); status != http.StatusInternalServerError || srv.count() != 1 {
+		t.Errorf("500: status %d after %d requests", status, srv.count())
+	}
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	srv := &flakyServer{statuses: []int{503, 503, 503, 503, 503}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	status, _ := get(t, New(testConfig()), ts.URL)
+	if status != http.StatusServiceUnavailable || srv.count() != 4 {
+		t.Errorf("status %d after %d requests, want 503 after 4", status, srv.count())
+	}
+}
+
+func TestBackoffGrowsAndIsCapped(t *testing.T) {
+	c := New(Config{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		for range 20 {
+			if d := c.backoff(attempt); d < want/2 || d >= want {
+				t.Fatalf("backoff(%d) = %s, want in [%s, %s)", attempt, d, want/2, want)
+			}
+		}
+	}
+	if d := c.backoff(80); d < 500*time.Millisecond || d >= time.Second {
+		t.Errorf("backoff(80) = %s, overflow not capped", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	// 按对方要求的时间等待，即使比自己的退避更长。HTTP 日期只精确到秒，请求时才生成
+	for _, tc := range []struct {
+		name   string
+		header func() string
+	}{
+		{"seconds", func() string { return "1" }},
+		{"http date", func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }},
+	} {
+		srv := &flakyServer{statuses: []int{http.StatusTooManyRequests}, retryAfter: tc.header()}
+		ts := httptest.NewServer(srv)
+		status, _ := get(t, New(testConfig()), ts.URL)
+		ts.Close()
+		if status != http.StatusOK || srv.count() != 2 {
+			t.Errorf("%s: status %d after %d requests", tc.name, status, srv.count())
+			continue
+		}
+		if gap := srv.gap(1); gap < 900*time.Millisecond {
+			t.Errorf("%s: retried after %s, want about a second", tc.name, gap)
+		}
+	}
+}
+
+func TestRetryAfterBeyondMaxBackoffGivesUp(t *testing.T) {
+	for _, header := range []string{
+		"86400",
+		time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat),
+	} {
+		srv := &flakyServer{statuses: []int{http.StatusTooManyRequests}, retryAfter: header}
+		ts := httptest.NewServer(srv)
+		status, elapsed := get(t, New(testConfig()), ts.URL)
+		ts.Close()
+		if status != http.StatusTooManyRequests || srv.count() != 1 || elapsed > time.Second {
+			t.Errorf("Retry-After %q: status %d after %d requests in %s, want to give up at once", header, status, srv.count(), elapsed)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		ok     bool
+	}{
+		{"120", true},
+		{" 0 ", true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), true},
+		{"", false},
+		{"-5", false},
+		{"soon", false},
+	} {
+		d, ok := retryAfter(tc.header)
+		if ok != tc.ok || d < 0 {
+			t.Errorf("retryAfter(%q) = %s, %v", tc.header, d, ok)
+		}
+	}
+	if d, _ := retryAfter("120"); d != 2*time.Minute {
+		t.Errorf("retryAfter(120) = %s", d)
+	}
+}
+
+func TestPerHostRateLimit(t *testing.T) {
+	srv := &flakyServer{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	// 同一个服务分别用 127.0.0.1 和 localhost 访问，两个 host 的限速互不影响
+	cfg := testConfig()
+	cfg.RateLimit = 10
+	cfg.HostRateLimits = map[string]float64{"localhost": 0} // 0 表示不限速
+	c := New(cfg)
+	limited := ts.URL
+	unlimited := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+
+	start := time.Now()
+	for range 5 {
+		get(t, c, unlimited)
+	}
+	if d := time.Since(start); d > 80*time.Millisecond {
+		t.Errorf("unlimited host took %s for 5 requests", d)
+	}
+
+	start = time.Now()
+	for range 4 {
+		get(t, c, limited)
+	}
+	// 容量为 1 的令牌桶，每秒 10 个：第一个请求立即发出，之后每个间隔 100ms
+	if d := time.Since(start); d < 280*time.Millisecond {
+		t.Errorf("limited host took only %s for 4 requests", d)
+	}
+}
@@ -0,0 +1,130 @@
+package crawlhttp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const robotsTTL = 24 * time.Hour
+
+// robots.txt 中与我们相关的规则，目前只使用 Crawl-delay
+type robotsRules struct {
+	crawlDelay time.Duration
+	fetchedAt  time.Time
+}
+
+// 每个 host 每 robotsTTL 读取一次 robots.txt，失败时按没有限制处理。
+// 与限速一样按主机名记录，robots.txt 从请求的 scheme、主机和端口读取
+func (c *Client) loadRobots(ctx context.Context, u *url.URL) {
+	host := u.Hostname()
+	c.mu.Lock()
+	rules, ok := c.robots[host]
+	if ok && time.Since(rules.fetchedAt) < robotsTTL {
+		c.mu.Unlock()
+		return
+	}
+	// 先占位，避免并发请求重复抓取
+	c.robots[host] = &robotsRules{fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	if err := c.limiter(host).Wait(ctx); err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+u.Host+"/robots.txt", nil)
+	if err != nil {
+		return
+	}
+	resp, err := c.once(req)
+	if err != nil {
+		log.Printf("读取 %s 的 robots.txt 失败: %v", host, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return
+	}
+
+	delay := parseCrawlDelay(io.LimitReader(resp.Body, 512<<10), productToken(c.cfg.UserAgent))
+	c.mu.Lock()
+	c.robots[host] = &robotsRules{crawlDelay: delay, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	c.applyCrawlDelay(host, delay)
+}
+
+// User-Agent 的产品名，如 "hpc-site-crawler/1.0 (...)" → "hpc-site-crawler"
+func productToken(ua string) string {
+	token, _, _ := strings.Cut(ua, "/")
+	token, _, _ = strings.Cut(token, " ")
+	return strings.ToLower(token)
+}
+
+// 解析匹配 agent 的分组中的 Crawl-delay，没有专属分组时使用 "*" 分组。
+// 按 RFC 9309 以产品名忽略大小写整体匹配，"hpc" 这样的分组不会匹配 hpc-site-crawler
+func parseCrawlDelay(r io.Reader, agent string) time.Duration {
+	var (
+		specific, wildcard       time.Duration
+		hasSpecific              bool
+		groupAgents              []string
+		inAgentLines, matchGroup bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !inAgentLines {
+				groupAgents = groupAgents[:0]
+			}
+			inAgentLines = true
+			groupAgents = append(groupAgents, productToken(value))
+			continue
+		}
+		if inAgentLines {
+			inAgentLines = false
+			matchGroup = false
+			for _, a := range groupAgents {
+				if a == "*" || (agent != "" && a == agent) {
+					matchGroup = true
+				}
+			}
+		}
+		if key != "crawl-delay" || !matchGroup {
+			continue
+		}
+
+		secs, err := strconv.ParseFloat(value, 64)
+		if err != nil || secs <= 0 {
+			continue
+		}
+		d := time.Duration(secs * float64(time.Second))
+		isWildcard := len(groupAgents) == 1 && groupAgents[0] == "*"
+		if isWildcard {
+			wildcard = d
+		} else {
+			specific, hasSpecific = d, true
+		}
+	}
+	if hasSpecific {
+		return specific
+	}
+	return wildcard
+}
@@ -0,0 +1,82 @@
+package crawlhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestParseCrawlDelay(t *testing.T) {
+	const agent = "hpc-site-crawler"
+	for _, tc := range []struct {
+		name   string
+		robots string
+		want   time.Duration
+	}{
+		{"wildcard", "User-agent: *\nCrawl-delay: 5\n", 5 * time.Second},
+		{"specific wins over wildcard", "User-agent: *\nCrawl-delay: 5\n\nUser-agent: hpc-site-crawler\nCrawl-delay: 2\n", 2 * time.Second},
+		{"case insensitive", "User-Agent: HPC-Site-Crawler\nCRAWL-DELAY: 1.5\n", 1500 * time.Millisecond},
+		{"agent with version", "User-agent: hpc-site-crawler/2.0\nCrawl-delay: 3\n", 3 * time.Second},
+		{"group with several agents", "User-agent: googlebot\nUser-agent: hpc-site-crawler\nCrawl-delay: 4\n", 4 * time.Second},
+		// 产品名的一部分不算匹配
+		{"substring group", "User-agent: hpc\nCrawl-delay: 30\n\nUser-agent: *\nCrawl-delay: 1\n", time.Second},
+		{"crawler substring", "User-agent: crawler\nCrawl-delay: 30\n", 0},
+		{"other agent only", "User-agent: googlebot\nCrawl-delay: 10\n", 0},
+		{"comments", "# crawl slowly\nUser-agent: * # everyone\nCrawl-delay: 2 # seconds\n", 2 * time.Second},
+		{"invalid delay", "User-agent: *\nCrawl-delay: soon\n", 0},
+		{"empty", "", 0},
+	} {
+		if got := parseCrawlDelay(strings.NewReader(tc.robots), agent); got != tc.want {
+			t.Errorf("%s: Crawl-delay %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestProductToken(t *testing.T) {
+	for ua, want := range map[string]string{
+		"hpc-site-crawler/1.0 (mailto:a@example.org)": "hpc-site-crawler",
+		"HPC-Site-Crawler": "hpc-site-crawler",
+		"curl/8.0":         "curl",
+	} {
+		if got := productToken(ua); got != want {
+			t.Errorf("productToken(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestRobotsCrawlDelaySlowsHost(t *testing.T) {
+	var robotsFetches atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			w.Write([]byte("User-agent: hpc-site-crawler\nCrawl-delay: 0.2\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	cfg := testConfig()
+	cfg.RespectRobots = true
+	c := New(cfg)
+
+	start := time.Now()
+	for range 3 {
+		get(t, c, ts.URL+"/page")
+	}
+	// 读取 robots.txt 之后限速降为每 200ms 一个，三个请求之间有两次间隔
+	if d := time.Since(start); d < 380*time.Millisecond {
+		t.Errorf("3 requests took %s, Crawl-delay not applied", d)
+	}
+	if n := robotsFetches.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", n)
+	}
+	if l := c.limiter("127.0.0.1").Limit(); l != rate.Every(200*time.Millisecond) {
+		t.Errorf("limit = %v", l)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/auth"
+	"hpc-site/internal/models"
+)
+
+type userRequest struct {
+	Name     string  `json:"name"`
+	Role     *string `json:"role"`
+	Disabled *bool   `json:"disabled"`
+}
+
+func validateRole(role string) error {
+	if !models.ValidRole(role) {
+		return fieldError("role", "role must be one of viewer, curator, admin")
+	}
+	return nil
+}
+
+func parseUserID(c *gin.Context) (int, bool) {
+	id, ok := parseIDParam(c, "invalid user id")
+	return int(id), ok
+}
+
+// GET /admin/users
+func (h *Handler) GetUsers(c *gin.Context) {
+	users, err := h.users.List(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// POST /admin/users {"name": "...", "role": "curator"}
+func (h *Handler) CreateUser(c *gin.Context) {
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	u := models.User{Name: strings.TrimSpace(req.Name), Role: models.RoleViewer}
+	if u.Name == "" || len(u.Name) > 100 {
+		c.Error(fieldError("name", "name is required and must be at most 100 characters"))
+		return
+	}
+	if req.Role != nil {
+		u.Role = *req.Role
+	}
+	if err := validateRole(u.Role); err != nil {
+		c.Error(err)
+		return
+	}
+	if req.Disabled != nil {
+		u.Disabled = *req.Disabled
+	}
+
+	if err := h.users.Create(c.Request.Context(), &u); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, u)
+}
+
+// PATCH /admin/users/:id {"role": "...", "disabled": true}，用户名不能修改
+func (h *Handler) PatchUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.users.GetByID(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if req.Role != nil {
+		if err := validateRole(*req.Role); err != nil {
+			c.Error(err)
+			return
+		}
+		u.Role = *req.Role
+	}
+	if req.Disabled != nil {
+		u.Disabled = *req.Disabled
+	}
+	// 避免管理员把自己锁在外面
+	if p := principal(c); p.UserID == u.ID && (u.Role != models.RoleAdmin || u.Disabled) {
+		c.Error(apperr.New(apperr.Conflict, "admins cannot demote or disable themselves"))
+		return
+	}
+
+	if err := h.users.Update(ctx, u); err != nil {
+		c.Error(err)
+		return
+	}
+	h.userCache.forget(u.ID)
+	c.JSON(http.StatusOK, u)
+}
+
+// GET /admin/users/:id/api-keys
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if _, err := h.users.GetByID(ctx, id); err != nil {
+		c.Error(err)
+		return
+	}
+	keys, err := h.users.ListAPIKeys(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// POST /admin/users/:id/api-keys {"name": "..."} 创建 API key，明文只在响应中出现这一次
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(invalidBody(err))
+			return
+		}
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	k := models.APIKey{UserID: id, Name: strings.TrimSpace(req.Name), Prefix: prefix}
+	if err := h.users.CreateAPIKey(c.Request.Context(), &k, hash); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": k})
+}
+
+// DELETE /admin/api-keys/:id 吊销 API key
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	id, ok := parseIDParam(c, "invalid api key id")
+	if !ok {
+		return
+	}
+	if err := h.users.RevokeAPIKey(c.Request.Context(), int(id)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -2,43 +2,112 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/config"
+	"hpc-site/internal/crawlhttp"
+	"hpc-site/internal/metrics"
 	"hpc-site/internal/models"
+	"hpc-site/internal/relevance"
 	"hpc-site/internal/repository"
+	"hpc-site/internal/source"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
-const SearchPattern = "https://arxiv.org/search/?query=%s&searchtype=all&abstracts=hide&order=-announced_date_first&size=50&start=%d"
-const SearchPageSize = 50 // arXiv 搜索页面默认每页显示 50 条
+// arXiv 搜索页地址（相对 sources.arxiv_web_url），按首次发布时间倒序
+const arxivSearchPath = "/search/?query=%s&searchtype=all&abstracts=hide&order=-announced_date_first&size=%d&start=%d"
+
+// 写入数据库的论文链接始终使用 arXiv 官方地址，不受 sources.arxiv_web_url 影响
+const arxivCanonicalURL = "https://arxiv.org"
+
+// 抓取论文用到的客户端、来源和参数，由 New 按配置创建
+type crawler struct {
+	client        *crawlhttp.Client // 所有抓取请求共用：按 host 限速、429/503 退避重试、带联系方式的 User-Agent
+	arxiv         *source.ArxivAPI
+	sources       []source.PaperSource          // 按配置顺序检索
+	bySource      map[string]source.PaperSource // 全部已知来源，含未启用的，刷新已入库论文时按其来源查找
+	arxivHTML     bool                          // arXiv 回退到解析网页，默认使用 Atom 导出 API
+	arxivWebURL   string
+	pageSize      int     // arXiv 搜索页每页结果数
+	concurrency   int     // 并发抓取论文详情的 worker 数，对同一 host 的请求仍受 client 限速
+	minConfidence float64 // 论文—软件关联直接发布的最低置信度，低于该值的进入审核队列
+}
 
-func FetchArxivSearchHtml(softwareName string, start int) (string, error) {
-	url := fmt.Sprintf(SearchPattern, softwareName, start)
-	for i := 0; i < 3; i++ { // 最多重试三次
-		resp, err := http.Get(url)
-		defer resp.Body.Close() //::TODO 在循环中调用 defer 有可能导致资源泄漏
+func newCrawler(cfg config.Crawler, sources config.Sources) *crawler {
+	httpCfg := crawlhttp.DefaultConfig()
+	httpCfg.UserAgent = cfg.UserAgent
+	if cfg.Mailto != "" {
+		httpCfg.UserAgent += " (mailto:" + cfg.Mailto + ")"
+	}
+	httpCfg.Timeout = cfg.Timeout
+	httpCfg.MaxRetries = cfg.MaxRetries
+	httpCfg.RateLimit = cfg.RateLimit
+	httpCfg.HostRateLimits = cfg.HostRateLimits
+	httpCfg.RespectRobots = cfg.RespectRobots
+
+	cr := &crawler{
+		client:        crawlhttp.New(httpCfg),
+		arxivHTML:     sources.ArxivBackend == "html",
+		arxivWebURL:   strings.TrimRight(sources.ArxivWebURL, "/"),
+		pageSize:      sources.ArxivSearchPageSize,
+		concurrency:   cfg.Concurrency,
+		minConfidence: cfg.MinConfidence,
+	}
+	cr.arxiv = source.NewArxivAPI(sources.ArxivAPIURL, cr.client)
+	cr.bySource = map[string]source.PaperSource{cr.arxiv.Name(): cr.arxiv}
+	for name, baseURL := range map[string]string{"crossref": sources.CrossrefURL, "openalex": sources.OpenAlexURL} {
+		src, err := source.New(name, baseURL, cfg.Mailto, cr.client)
 		if err != nil {
-			log.Printf("⚠️ 第 %d 次请求失败: %v", i+1, err)
-			time.Sleep(2 * time.Second)
+			log.Fatalf("❌ 创建论文来源 %s 失败: %v", name, err)
+		}
+		cr.bySource[name] = src
+	}
+	for _, name := range sources.Enabled {
+		src, ok := cr.bySource[name]
+		if !ok {
+			log.Printf("忽略未知的论文来源: %s", name)
 			continue
 		}
+		cr.sources = append(cr.sources, src)
+	}
+	return cr
+}
 
-		if resp.StatusCode == http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return string(body), nil
-		}
-		log.Printf("⚠️ 返回状态码 %d，重试中...", resp.StatusCode)
-		time.Sleep(2 * time.Second)
+// 读取页面内容，非 200 时返回错误
+func (cr *crawler) fetchPage(ctx context.Context, url string) (string, error) {
+	resp, err := cr.client.Get(ctx, url)
+	if err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("连续请求失败: %s", url)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("请求 %s 返回状态码 %d", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (cr *crawler) FetchArxivSearchHtml(ctx context.Context, query string, start int) (string, error) {
+	return cr.fetchPage(ctx, cr.arxivWebURL+fmt.Sprintf(arxivSearchPath, url.QueryEscape(query), cr.pageSize, start))
 }
 
 func GetArxivIDsFromSearchHtml(html string) []string {
@@ -81,17 +150,27 @@ func MatchTotalResults(html string) int {
 	return 0
 }
 
-func CrawlArxivAll(softwareName string) []string {
+// 分页抓取搜索结果中的论文 ID，首页即失败时返回错误，ctx 取消时中止；
+// 结果按首次发布时间倒序，stopAtID 非空时翻到不比它新的论文即停止（增量抓取）。
+// 返回的 ID 保持搜索结果的顺序，断点恢复按论文在其中的位置跳过已处理的部分
+func (cr *crawler) CrawlArxivAll(ctx context.Context, query string, stopAtID string) ([]string, error) {
 	start := 0
 	page := 1
-	allIDs := make(map[string]bool)
+	var allIDs []string
+	seen := make(map[string]bool)
 	total := 0
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		log.Printf("第 %d 页 start=%d", page, start)
-		html, err := FetchArxivSearchHtml(softwareName, start)
+		html, err := cr.FetchArxivSearchHtml(ctx, query, start)
 		if err != nil {
 			log.Printf("获取失败: %v", err)
+			if page == 1 {
+				return nil, err
+			}
 			break
 		}
 
@@ -105,77 +184,90 @@ func CrawlArxivAll(softwareName string) []string {
 
 		ids := GetArxivIDsFromSearchHtml(html)
 		log.Printf("第 %d 页解析出 %d 条", page, len(ids))
+		reachedSeen := false
 		for _, id := range ids {
-			allIDs[id] = true
+			if !seen[id] {
+				seen[id] = true
+				allIDs = append(allIDs, id)
+			}
+			if stopAtID != "" && !arxivIDNewer(id, stopAtID) {
+				reachedSeen = true
+			}
+		}
+		if reachedSeen {
+			log.Printf("已翻到上次抓取过的论文 %s，停止翻页: 共 %d 唯一论文", stopAtID, len(allIDs))
+			break
 		}
 
 		// 检查是否已到末页
-		if len(ids) == 0 || start+SearchPageSize >= total {
+		if len(ids) == 0 || start+cr.pageSize >= total {
 			log.Printf("抓取结束: 共 %d 唯一论文", len(allIDs))
 			break
 		}
 
-		start += SearchPageSize
+		start += cr.pageSize
 		page++
-		time.Sleep(2 * time.Second)
 	}
+	return allIDs, nil
+}
 
-	// 转成 slice
-	result := make([]string, 0, len(allIDs))
-	for id := range allIDs {
-		result = append(result, id)
+// 比较新格式的 arXiv ID（YYMM.NNNNN），a 比 b 新时返回 true；无法解析时按字符串比较
+func arxivIDNewer(a, b string) bool {
+	am, bm := arxivNewIDRe.FindStringSubmatch(a), arxivNewIDRe.FindStringSubmatch(b)
+	if am == nil || bm == nil {
+		return a > b
 	}
-	return result
+	ay, an, _ := strings.Cut(am[1], ".")
+	by, bn, _ := strings.Cut(bm[1], ".")
+	if ay != by {
+		return ay > by
+	}
+	x, _ := strconv.Atoi(an)
+	y, _ := strconv.Atoi(bn)
+	return x > y
 }
 
 // loop to get all papers by paper-id
-func GetArxivPageSource(id string, isWithDrawn bool, version int) string {
-	url := FormatPageUrl(id, isWithDrawn, version)
-	fmt.Println(url)
-	resp, err := http.Get(url)
+func (cr *crawler) GetArxivPageSource(ctx context.Context, id string, isWithDrawn bool, version int) string {
+	url := cr.arxivWebURL + arxivAbsPath(id, isWithDrawn, version)
+	body, err := cr.fetchPage(ctx, url)
 	if err != nil {
 		log.Printf("Error fetching data: %v", err)
 		return ""
 	}
-	defer resp.Body.Close()
-
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Error: received status code %d", resp.StatusCode)
-		return ""
-	}
-
-	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading response body: %v", err)
-		return ""
-	}
-	return string(body)
+	return body
 }
 
 // 详情页的
 func FormatPageUrl(id string, isWithDrawn bool, version int) string {
+	return arxivCanonicalURL + arxivAbsPath(id, isWithDrawn, version)
+}
+
+func arxivAbsPath(id string, isWithDrawn bool, version int) string {
 	if isWithDrawn {
-		return fmt.Sprintf("https://arxiv.org/abs/%sv%d", id, version)
+		return fmt.Sprintf("/abs/%sv%d", id, version)
 	} else {
-		return fmt.Sprintf("https://arxiv.org/abs/%s", id)
+		return fmt.Sprintf("/abs/%s", id)
 	}
 }
 
-func GetPaperFromMetaData(extractedId string, software string) models.Paper {
-	sourceCode := GetArxivPageSource(extractedId, false, 0)
+func (cr *crawler) GetPaperFromMetaData(ctx context.Context, extractedId string, software string) models.Paper {
+	sourceCode := cr.GetArxivPageSource(ctx, extractedId, false, 0)
 	isLatestVersionWithDrawn := IsWithDrawn(sourceCode)
 	title := MatchTitle(sourceCode)
 	authors := MatchAuthors(sourceCode)
 	abstract := MatchAbstract(sourceCode)
+	recordParseFailure(sourceCode, "MatchTitle", title != "")
+	recordParseFailure(sourceCode, "MatchAuthors", len(authors) > 0)
 	if isLatestVersionWithDrawn {
 		version := FindLastValidVersion(sourceCode)
 		url := FormatPageUrl(extractedId, true, version)
-		code := GetArxivPageSource(extractedId, true, version)
+		code := cr.GetArxivPageSource(ctx, extractedId, true, version)
 		log.Println("try to find latest valid version")
 		pdf := MatchPdf(code)
 		publishedTime := MatchSubmissionDate(code, isLatestVersionWithDrawn, version)
+		recordParseFailure(code, "MatchPdf", pdf != arxivCanonicalURL)
+		recordParseFailure(code, "MatchSubmissionDate", publishedTime != "")
 		return models.Paper{
 			ID:            extractedId,
 			Title:         title,
@@ -184,13 +276,16 @@ func GetPaperFromMetaData(extractedId string, software string) models.Paper {
 			URL:           url,
 			Pdf:           pdf,
 			PublishedTime: publishedTime,
+			Withdrawn:     true,
 			SoftwareNames: []string{software},
 		}
 	} else {
-		url := fmt.Sprintf("https://arxiv.org/abs/%s", extractedId)
+		url := FormatPageUrl(extractedId, false, 0)
 		log.Println("source code get,start to match content")
 		pdf := MatchPdf(sourceCode)
 		publishedTime := MatchSubmissionDate(sourceCode, false, 0)
+		recordParseFailure(sourceCode, "MatchPdf", pdf != arxivCanonicalURL)
+		recordParseFailure(sourceCode, "MatchSubmissionDate", publishedTime != "")
 		return models.Paper{
 			ID:            extractedId,
 			Title:         title,
@@ -204,6 +299,13 @@ func GetPaperFromMetaData(extractedId string, software string) models.Paper {
 	}
 }
 
+// 页面抓到了但提取结果为空时计数；页面没抓到（source 为空）的情况已经记在抓取的请求指标里
+func recordParseFailure(source, extractor string, ok bool) {
+	if source != "" && !ok {
+		metrics.CrawlerParseFailures.WithLabelValues(extractor).Inc()
+	}
+}
+
 func IsWithDrawn(source string) bool {
 	return strings.Contains(source, "This paper has been withdrawn by")
 }
@@ -252,8 +354,7 @@ func FindLastValidVersion(source string) int {
 
 func MatchPdf(source string) string {
 	regex := `<a\s*href="(.*)?"\s*aria-describedby="download-button-info" accesskey="f" class="abs-button download-pdf">View PDF<\/a>`
-	fmt.Println(MatchContent(source, regex))
-	return fmt.Sprintf("https://arxiv.org%s", MatchContent(source, regex))
+	return arxivCanonicalURL + MatchContent(source, regex)
 }
 
 func MatchSubmissionDate(source string, isWithDrawn bool, version int) string {
@@ -266,6 +367,10 @@ func MatchSubmissionDate(source string, isWithDrawn bool, version int) string {
 			submissionArray = append(submissionArray, item)
 		}
 	}
+	// 页面结构变化或没抓到时找不到提交记录
+	if len(submissionArray) == 0 || (isWithDrawn && (version < 1 || version > len(submissionArray))) {
+		return ""
+	}
 	if !isWithDrawn {
 		matchedTime := strings.TrimSpace(MatchContent(submissionArray[len(submissionArray)-1], `(.*?UTC)`))
 		return matchedTime
@@ -306,79 +411,567 @@ func splitAuthors(raw string) []string {
 	return out
 }
 
-func ProcessSoftwarePapers(softwareName string) {
-	paperIds := CrawlArxivAll(softwareName)
-	//只有paper不存在的情况才需要去抓
-	log.Printf("开始抓取与软件 [%s] 相关的论文", softwareName)
-	for i, paperId := range paperIds {
-		log.Printf("[%d/%d] 检查论文 %s 是否存在", i+1, len(paperIds), paperId)
-		//判断是否存在
-		exists, existingSoftwares, err := repository.CheckPaperExists(paperId)
+// 候选论文：ID、DOI 和按需获取详情的方法，API 类来源在检索时已拿到全部元数据
+type paperCandidate struct {
+	ID    string
+	DOI   string
+	Fetch func(ctx context.Context) models.Paper
+}
+
+// 从所有启用的来源收集候选论文，只有全部来源都失败时才返回错误；
+// 按软件名和别名做短语检索并排除 exclude_terms，
+// state 非 nil 时做增量检索，支持增量的来源只翻到上次抓取之前的结果
+func (cr *crawler) crawlCandidates(ctx context.Context, sw *models.Software, state *models.SoftwareCrawlState) ([]paperCandidate, error) {
+	softwareName := sw.Name
+	q := source.NewQuery(sw.Name, sw.Aliases, sw.ExcludeTerms)
+	log.Printf("[%s] 检索条件: %s", softwareName, q)
+	var candidates []paperCandidate
+	var errs []error
+	for _, src := range cr.sources {
+		var found []paperCandidate
+		var err error
+		if src.Name() == "arxiv" && cr.arxivHTML {
+			stopAtID := ""
+			if state != nil {
+				stopAtID = state.NewestArxivID
+			}
+			found, err = cr.crawlArxivHTMLCandidates(ctx, q, stopAtID)
+		} else {
+			found, err = crawlSourceCandidates(ctx, src, q, state)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("[%s] 从 %s 检索论文失败: %v", softwareName, src.Name(), err)
+			errs = append(errs, apperr.Wrap(apperr.Unavailable, "search "+src.Name()+" failed", err))
+			continue
+		}
+		log.Printf("[%s] 从 %s 检索到 %d 篇论文", softwareName, src.Name(), len(found))
+		candidates = append(candidates, found...)
+	}
+	if len(candidates) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return candidates, nil
+}
+
+// 网页搜索不支持布尔语法，逐个名称按短语检索后合并，排除词交给相关度评分处理
+func (cr *crawler) crawlArxivHTMLCandidates(ctx context.Context, q source.Query, stopAtID string) ([]paperCandidate, error) {
+	var candidates []paperCandidate
+	seen := make(map[string]bool)
+	for i, term := range q.Terms {
+		ids, err := cr.CrawlArxivAll(ctx, `"`+term+`"`, stopAtID)
 		if err != nil {
-			log.Printf("检查论文 %s 是否存在时出错: %v", paperId, err)
+			if i == 0 {
+				return nil, err
+			}
+			log.Printf("按别名 %q 检索失败: %v", term, err)
 			continue
 		}
-		//存在则只更新software
-		if exists {
-			merged := repository.MergeUnique(existingSoftwares, []string{softwareName})
-			if len(merged) != len(existingSoftwares) {
-				log.Printf("论文 %s 已存在，合并软件 [%s]", paperId, softwareName)
-				err := repository.UpdatePaperSoftware(paperId, merged)
-				if err != nil {
-					log.Printf("更新论文 %s 软件失败: %v", paperId, err)
-				} else {
-					log.Printf("已为论文 %s 添加新软件 [%s]", paperId, softwareName)
-				}
-			} else {
-				log.Printf("论文与软件已经存在，无需更新")
+		for _, id := range ids {
+			if seen[id] {
 				continue
 			}
-		} else {
-			//paper不存在就去抓详情页
-			paper := GetPaperFromMetaData(paperId, softwareName)
-			if paper.ID == "" || paper.Title == "" {
-				log.Printf("论文 %s 抓取失败，跳过", paperId)
-				continue
+			seen[id] = true
+			candidates = append(candidates, paperCandidate{
+				ID:    id,
+				Fetch: func(ctx context.Context) models.Paper { return cr.GetPaperFromMetaData(ctx, id, "") },
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// arXiv 通常在提交后一两天才公开论文，增量检索时多往前回看一段时间
+const incrementalOverlap = 72 * time.Hour
+
+func crawlSourceCandidates(ctx context.Context, src source.PaperSource, q source.Query, state *models.SoftwareCrawlState) ([]paperCandidate, error) {
+	var papers []models.Paper
+	var err error
+	if inc, ok := src.(source.IncrementalSource); ok && state != nil && state.LastSuccessAt != nil {
+		since := state.LastSuccessAt.Add(-incrementalOverlap)
+		log.Printf("增量检索 %s 自 %s 以来提交的论文", src.Name(), since.Format(time.DateOnly))
+		papers, err = inc.SearchSince(ctx, q, since)
+	} else {
+		papers, err = src.Search(ctx, q)
+	}
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]paperCandidate, 0, len(papers))
+	for _, p := range papers {
+		candidates = append(candidates, paperCandidate{
+			ID:    p.ID,
+			DOI:   p.DOI,
+			Fetch: func(context.Context) models.Paper { return p },
+		})
+	}
+	return candidates, nil
+}
+
+// 从论文的来源获取单篇论文的最新元数据，id 为入库时的论文 ID
+func (cr *crawler) fetchPaper(ctx context.Context, sourceName, id string) (models.Paper, error) {
+	if sourceName != cr.arxiv.Name() {
+		src, ok := cr.bySource[sourceName]
+		if !ok {
+			return models.Paper{}, fmt.Errorf("unknown paper source %q", sourceName)
+		}
+		return src.Fetch(ctx, id)
+	}
+	if cr.arxivHTML {
+		paper := cr.GetPaperFromMetaData(ctx, id, "")
+		if paper.Title == "" {
+			return paper, fmt.Errorf("fetch paper %s: no metadata", id)
+		}
+		return paper, nil
+	}
+	return cr.arxiv.Fetch(ctx, id)
+}
+
+// 按 ID、DOI 去重，保留先出现的候选（来源顺序靠前的优先）
+func dedupeCandidates(candidates []paperCandidate) []paperCandidate {
+	seenID := make(map[string]bool, len(candidates))
+	seenDOI := make(map[string]bool)
+	out := make([]paperCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		doi := strings.ToLower(c.DOI)
+		if seenID[c.ID] || (doi != "" && seenDOI[doi]) {
+			continue
+		}
+		seenID[c.ID] = true
+		if doi != "" {
+			seenDOI[doi] = true
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// 批量查出候选论文中已入库的部分，返回候选 ID → 已入库论文（可能是 DOI 相同的其他来源论文）
+func (h *Handler) lookupExistingPapers(ctx context.Context, candidates []paperCandidate) (map[string]repository.ExistingPaper, error) {
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	existing, err := h.papers.GetExisting(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一篇论文可能已经从其他来源入库，按 DOI 去重
+	var dois []string
+	for _, c := range candidates {
+		if _, ok := existing[c.ID]; !ok && c.DOI != "" {
+			dois = append(dois, c.DOI)
+		}
+	}
+	if len(dois) == 0 {
+		return existing, nil
+	}
+	byDOI, err := h.papers.GetExistingByDOI(ctx, dois)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range candidates {
+		if _, ok := existing[c.ID]; ok || c.DOI == "" {
+			continue
+		}
+		if p, ok := byDOI[strings.ToLower(c.DOI)]; ok {
+			log.Printf("论文 %s 与已存在的论文 %s DOI 相同", c.ID, p.ID)
+			existing[c.ID] = p
+		}
+	}
+	return existing, nil
+}
+
+// 单篇论文的处理结果
+type paperOutcome int
+
+const (
+	paperInserted paperOutcome = iota
+	paperUpdated
+	paperSkipped
+	paperQueued // 置信度不足，进入审核队列
+)
+
+// 候选论文每页的篇数，每处理完一页批量入库一次并保存断点
+const paperBatchSize = 50
+
+// 抓取到详情、等待批量入库的新论文
+type pendingPaper struct {
+	paper models.Paper
+	match relevance.Match
+}
+
+// 处理单篇候选论文：按标题和摘要评分，置信度足够的直接关联到软件，否则放入审核队列；
+// 已存在的论文只处理关联，不存在的抓取详情后返回 pendingPaper，由调用方批量入库
+func (h *Handler) processCandidate(ctx context.Context, candidate paperCandidate, existing repository.ExistingPaper, exists bool, sw *models.Software, scorer *relevance.Scorer, threshold float64) (paperOutcome, *pendingPaper, error) {
+	//存在则只更新software
+	if exists {
+		if slices.Contains(existing.Linked, int64(sw.ID)) || slices.Contains(existing.Reviewed, int64(sw.ID)) {
+			return paperSkipped, nil, nil
+		}
+		match := scorer.Score(existing.Title, existing.Abstract)
+		if match.Confidence < threshold {
+			outcome, err := h.queueReview(ctx, existing.ID, sw, match)
+			return outcome, nil, err
+		}
+		if err := h.papers.LinkSoftware(ctx, existing.ID, sw.ID, repository.LinkSourceCrawl, match.Confidence); err != nil {
+			return 0, nil, apperr.Wrap(apperr.Internal, "update paper "+existing.ID+" failed", err)
+		}
+		log.Printf("已为论文 %s 添加新软件 [%s]，置信度 %.2f", existing.ID, sw.Name, match.Confidence)
+		return paperUpdated, nil, nil
+	}
+
+	//paper不存在就去抓详情页
+	paper := candidate.Fetch(ctx)
+	if paper.ID == "" || paper.Title == "" {
+		return 0, nil, apperr.Errorf(apperr.Unavailable, "fetch paper %s: no metadata", candidate.ID)
+	}
+	match := scorer.Score(paper.Title, paper.Abstract)
+	paper.SoftwareNames = []string{}
+	if match.Confidence >= threshold {
+		paper.SoftwareNames = []string{sw.Name}
+		paper.SoftwareConfidence = map[string]float64{sw.Name: match.Confidence}
+	}
+	return 0, &pendingPaper{paper: paper, match: match}, nil
+}
+
+// 在一个事务中批量写入新论文、软件关联和置信度不足的审核记录，再逐篇汇报结果；
+// 单篇失败只影响这一篇，不会让整页的论文都重新抓取
+func (h *Handler) insertPapers(ctx context.Context, batch []pendingPaper, sw *models.Software, report func(paperOutcome, error)) {
+	if len(batch) == 0 {
+		return
+	}
+	papers := make([]models.Paper, len(batch))
+	var reviews []models.PaperReview
+	for i, p := range batch {
+		papers[i] = p.paper
+		if len(p.paper.SoftwareNames) == 0 {
+			reviews = append(reviews, newReview(p.paper.ID, sw, p.match))
+		}
+	}
+	failed, err := h.papers.UpsertBatch(ctx, papers, reviews)
+	for _, p := range batch {
+		switch {
+		case err != nil:
+			report(0, apperr.Wrap(apperr.Internal, "insert paper "+p.paper.ID+" failed", err))
+		case failed[p.paper.ID] != nil:
+			report(0, apperr.Wrap(apperr.Internal, "insert paper "+p.paper.ID+" failed", failed[p.paper.ID]))
+		case len(p.paper.SoftwareNames) == 0:
+			log.Printf("论文 %s 与软件 [%s] 的置信度 %.2f 过低，已放入审核队列", p.paper.ID, sw.Name, p.match.Confidence)
+			report(paperQueued, nil)
+		default:
+			log.Printf("✅ 成功插入论文 %s，置信度 %.2f", p.paper.ID, p.match.Confidence)
+			report(paperInserted, nil)
+		}
+	}
+}
+
+func newReview(paperID string, sw *models.Software, match relevance.Match) models.PaperReview {
+	return models.PaperReview{
+		PaperID:    paperID,
+		SoftwareID: sw.ID,
+		Confidence: match.Confidence,
+		Reasons:    match.Reasons,
+	}
+}
+
+func (h *Handler) queueReview(ctx context.Context, paperID string, sw *models.Software, match relevance.Match) (paperOutcome, error) {
+	review := newReview(paperID, sw, match)
+	if err := h.reviews.Queue(ctx, &review); err != nil {
+		return 0, apperr.Wrap(apperr.Internal, "queue review for paper "+paperID+" failed", err)
+	}
+	log.Printf("论文 %s 与软件 [%s] 的置信度 %.2f 过低，已放入审核队列", paperID, sw.Name, match.Confidence)
+	return paperQueued, nil
+}
+
+// 读取软件上次的抓取状态，没有成功抓取过或出错时返回 nil（做完整抓取）
+func (h *Handler) incrementalState(ctx context.Context, softwareName string) *models.SoftwareCrawlState {
+	state, err := h.crawlState.Get(ctx, softwareName)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("[%s] 查询抓取状态失败，改为完整抓取: %v", softwareName, err)
+		}
+		return nil
+	}
+	if state.LastSuccessAt == nil {
+		return nil
+	}
+	return state
+}
+
+// 记录本次抓取：只有检索和入库都没有出错时才更新 last_success_at 和最新 arXiv ID，
+// 否则下次增量抓取仍从上次成功的时间开始，不会漏掉本次失败的论文。任务被取消时也要记录，因此不跟随 ctx 取消
+func (h *Handler) saveCrawlState(ctx context.Context, softwareName string, startedAt time.Time, candidates []paperCandidate, succeeded bool) {
+	ctx = context.WithoutCancel(ctx)
+	state, err := h.crawlState.Get(ctx, softwareName)
+	if err != nil {
+		state = &models.SoftwareCrawlState{}
+	}
+	state.LastCrawledAt = &startedAt
+	if succeeded {
+		state.LastSuccessAt = &startedAt
+		for _, c := range candidates {
+			if arxivNewIDRe.MatchString(c.ID) && (state.NewestArxivID == "" || arxivIDNewer(c.ID, state.NewestArxivID)) {
+				state.NewestArxivID = c.ID
 			}
-			err = repository.InsertNewPaper(paper)
-			if err != nil {
-				if err != nil {
-					log.Printf("插入论文 %s 失败: %v", paperId, err)
-				} else {
-					log.Printf("✅ 成功插入论文 %s", paperId)
+		}
+	}
+	if err := h.crawlState.Save(ctx, softwareName, state); err != nil {
+		log.Printf("[%s] 保存抓取状态失败: %v", softwareName, err)
+	}
+}
+
+// 单个软件的抓取选项
+type crawlOptions struct {
+	Full         bool                           // 忽略上次的抓取状态，翻完全部结果
+	Resume       *models.CrawlCheckpoint        // 非 nil 时从断点继续
+	OnPaper      func(models.CrawlStats, error) // 每处理完一篇调用，汇报累计计数和该篇的错误
+	OnCheckpoint func(models.CrawlCheckpoint)   // 每处理完一页调用
+}
+
+// 抓取某个软件的论文并入库，候选论文按页处理，每页的详情由有界 worker 池并发抓取，
+// 一页处理完后批量入库并通过 OnCheckpoint 汇报断点，ctx 取消时停在当前页。
+// 默认只增量抓取上次成功抓取之后的论文，Full 为 true 时翻完全部结果。回调都是串行调用的，可为 nil
+func (h *Handler) ProcessSoftwarePapers(ctx context.Context, softwareName string, opts crawlOptions) (models.CrawlStats, error) {
+	var stats models.CrawlStats
+	startedAt := time.Now()
+	if opts.Resume != nil {
+		startedAt = opts.Resume.StartedAt
+	}
+	sw, err := h.software.GetByName(ctx, softwareName)
+	if err != nil {
+		return stats, fmt.Errorf("query software: %w", err)
+	}
+	var state *models.SoftwareCrawlState
+	if !opts.Full {
+		state = h.incrementalState(ctx, softwareName)
+	}
+	candidates, err := h.crawler.crawlCandidates(ctx, sw, state)
+	if err != nil {
+		h.saveCrawlState(ctx, softwareName, startedAt, nil, false)
+		return stats, err
+	}
+	candidates = dedupeCandidates(candidates)
+	stats.Found = len(candidates)
+
+	existing, err := h.lookupExistingPapers(ctx, candidates)
+	if err != nil {
+		h.saveCrawlState(ctx, softwareName, startedAt, nil, false)
+		return stats, apperr.Wrap(apperr.Internal, "check existing papers failed", err)
+	}
+	log.Printf("开始处理与软件 [%s] 相关的 %d 篇论文，其中 %d 篇已入库", softwareName, len(candidates), len(existing))
+
+	var mu sync.Mutex
+	report := func(outcome paperOutcome, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var label string
+		switch {
+		case err != nil:
+			log.Printf("[%s] %v", softwareName, err)
+			stats.Failed++
+			label = "failed"
+		case outcome == paperInserted:
+			stats.Inserted++
+			label = "inserted"
+		case outcome == paperUpdated:
+			stats.Updated++
+			label = "updated"
+		case outcome == paperQueued:
+			stats.Queued++
+			label = "queued"
+		default:
+			stats.Skipped++
+			label = "skipped"
+		}
+		metrics.CrawlerPapers.WithLabelValues(softwareName, label).Inc()
+		if opts.OnPaper != nil {
+			opts.OnPaper(stats, err)
+		}
+	}
+
+	checkpoint := models.CrawlCheckpoint{StartedAt: startedAt}
+	start := 0
+	if opts.Resume != nil {
+		checkpoint.Page = opts.Resume.Page
+		checkpoint.LastID = opts.Resume.LastID
+		if i := slices.IndexFunc(candidates, func(c paperCandidate) bool { return c.ID == opts.Resume.LastID }); i >= 0 {
+			start = i + 1
+			log.Printf("[%s] 从断点继续: 已处理 %d 页，跳过前 %d 篇", softwareName, opts.Resume.Page, start)
+		} else {
+			log.Printf("[%s] 检索结果中找不到断点 %s，从头处理", softwareName, opts.Resume.LastID)
+		}
+	}
+
+	// 软件名、别名和排除词的正则只编译一次，整个任务中复用
+	scorer := relevance.NewScorer(*sw)
+	threshold := h.crawler.minConfidence
+	for ; start < len(candidates) && ctx.Err() == nil; start += paperBatchSize {
+		page := candidates[start:min(start+paperBatchSize, len(candidates))]
+		if !h.processPage(ctx, page, existing, sw, scorer, threshold, report) {
+			break
+		}
+		checkpoint.Page++
+		checkpoint.LastID = page[len(page)-1].ID
+		if opts.OnCheckpoint != nil {
+			opts.OnCheckpoint(checkpoint)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		h.saveCrawlState(ctx, softwareName, startedAt, nil, false)
+		return stats, err
+	}
+	h.saveCrawlState(ctx, softwareName, startedAt, candidates, stats.Failed == 0)
+	log.Printf("[%s] 抓取完成: %+v", softwareName, stats)
+	return stats, nil
+}
+
+// 并发处理一页候选论文，新论文在整页处理完后一次性入库；ctx 取消时不再派发，
+// 已抓到详情的新论文照常入库，返回 false 表示这一页没有处理完，恢复时需要重做
+func (h *Handler) processPage(ctx context.Context, page []paperCandidate, existing map[string]repository.ExistingPaper, sw *models.Software, scorer *relevance.Scorer, threshold float64, report func(paperOutcome, error)) bool {
+	var mu sync.Mutex
+	var pending []pendingPaper
+	jobs := make(chan paperCandidate)
+	var wg sync.WaitGroup
+	for range min(h.crawler.concurrency, len(page)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidate := range jobs {
+				p, exists := existing[candidate.ID]
+				outcome, newPaper, err := h.processCandidate(ctx, candidate, p, exists, sw, scorer, threshold)
+				switch {
+				case newPaper != nil:
+					mu.Lock()
+					pending = append(pending, *newPaper)
+					mu.Unlock()
+				case err != nil && ctx.Err() != nil:
+					// 被取消导致的失败不计数，恢复时会重做这一页
+				default:
+					report(outcome, err)
 				}
 			}
+		}()
+	}
+
+	complete := true
+feed:
+	for _, candidate := range page {
+		select {
+		case <-ctx.Done():
+			complete = false
+			break feed
+		case jobs <- candidate:
 		}
 	}
-	log.Printf("[%s] 抓取完成", softwareName)
+	close(jobs)
+	wg.Wait()
+	// 已经抓到的详情不浪费，即使 ctx 已取消也写入
+	h.insertPapers(context.WithoutCancel(ctx), pending, sw, report)
+	return complete && ctx.Err() == nil
+}
 
+// POST /crawl/all 创建抓取全部软件论文的异步任务，默认增量抓取，?full=true 时完整重新抓取
+func (h *Handler) GetAllSoftwarePaper(c *gin.Context) {
+	jobID, err := h.submitAllSoftwareCrawl(c.Request.Context(), c.Query("full") == "true")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": jobID,
+		"status": fmt.Sprintf("/crawl/jobs/%d", jobID),
+	})
 }
 
-//	func TestLammps(c *gin.Context) {
-//		ProcessSoftwarePapers("Lammps")
-//		c.JSON(http.StatusOK, gin.H{
-//			"message": "test",
-//		})
-//	}
-func GetAllSoftwarePaper(c *gin.Context) {
-	ctx := context.Background()
+// 创建抓取全部软件论文的任务，返回任务 ID
+func (h *Handler) submitAllSoftwareCrawl(ctx context.Context, full bool) (int64, error) {
 	//先从数据库获取所有的software
-	softwares, err := repository.QuerySoftware(ctx, "", "", "", "")
+	softwares, err := h.software.Query(ctx, repository.SoftwareFilter{}, repository.PageRequest{})
 	if err != nil {
-		log.Fatalf("查询软件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询软件失败"})
+		return 0, fmt.Errorf("查询软件失败: %w", err)
+	}
+	names := make([]string, 0, len(softwares.Items))
+	for _, s := range softwares.Items {
+		names = append(names, s.Name)
+	}
+	return h.crawlJobs.Submit(ctx, names, full)
+}
+
+// POST /softwares/:id/crawl 创建只抓取单个软件论文的异步任务，?full=true 时完整重新抓取
+func (h *Handler) CrawlSoftware(c *gin.Context) {
+	id, ok := parseSoftwareID(c)
+	if !ok {
 		return
 	}
-	for _, s := range softwares {
-		log.Printf("开始抓取 %s 相关的论文", s.Name)
-		ProcessSoftwarePapers(s.Name)
+
+	ctx := c.Request.Context()
+	software, err := h.software.GetByID(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "所有软件论文抓取任务已完成",
+
+	jobID, err := h.crawlJobs.Submit(ctx, []string{software.Name}, c.Query("full") == "true")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": jobID,
+		"status": fmt.Sprintf("/crawl/jobs/%d", jobID),
 	})
 }
 
-func TestSinglePaper(c *gin.Context) {
-	//"https://arxiv.org/abs/2405.20629" got some error
+var (
+	// 新格式的 arXiv ID（YYMM.NNNNN），可以按编号比较新旧
+	arxivNewIDRe = regexp.MustCompile(`^(\d{4}\.\d{4,5})(v\d+)?$`)
+	// 新旧格式的 arXiv ID，旧格式如 hep-lat/0601001、math.GT/0309136
+	arxivIDRe = regexp.MustCompile(`^(\d{4}\.\d{4,5}|[a-z-]+(?:\.[A-Z]{2})?/\d{7})(v\d+)?$`)
+)
+
+// POST /papers/:id/refresh 从论文的来源重新抓取元数据并覆盖已存储的记录。
+// id 为入库时的论文 ID：arXiv ID（可带版本号）、doi:...、openalex:W...，其中的 / 需编码为 %2F
+func (h *Handler) RefreshPaper(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if m := arxivIDRe.FindStringSubmatch(id); m != nil {
+		id = m[1]
+	} else if doi, ok := strings.CutPrefix(id, "doi:"); ok {
+		// 入库时 DOI 统一转为小写
+		id = "doi:" + strings.ToLower(doi)
+	}
+
+	ctx := c.Request.Context()
+	stored, err := h.papers.GetByID(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	paper, err := h.crawler.fetchPaper(ctx, stored.Source, id)
+	if errors.Is(err, source.ErrNotFound) {
+		c.Error(apperr.Wrap(apperr.NotFound, "paper not found on "+stored.Source, err))
+		return
+	}
+	if err != nil {
+		c.Error(apperr.Wrap(apperr.Unavailable, "failed to fetch paper metadata from "+stored.Source, err))
+		return
+	}
+	// 来源可能返回合并后的新 ID（如 OpenAlex 合并了重复的 Work），仍更新原记录
+	paper.ID = id
+	if err := h.papers.UpdateMetadata(ctx, paper); err != nil {
+		c.Error(err)
+		return
+	}
 
+	updated, err := h.papers.GetByID(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
 }
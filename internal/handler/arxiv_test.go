@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hpc-site/internal/config"
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
+)
+
+// 模拟 arXiv 网页：搜索页按 ids 的顺序分页返回结果，详情页的标题和摘要都提到 LAMMPS，记录被抓取的详情页
+type fakeArxiv struct {
+	ids      []string // 按首次发布时间倒序
+	pageSize int
+
+	mu      sync.Mutex
+	fetched []string
+}
+
+func (f *fakeArxiv) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/search/":
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		var b strings.Builder
+		fmt.Fprintf(&b, "<h1>Showing %d&ndash;%d of %d results</h1><ol>", start+1, start+f.pageSize, len(f.ids))
+		for _, id := range f.ids[min(start, len(f.ids)):min(start+f.pageSize, len(f.ids))] {
+			fmt.Fprintf(&b, `<li class="arxiv-result"><a href="https://arxiv.org/abs/%s">arXiv:%s</a></li>`, id, id)
+		}
+		b.WriteString("</ol>")
+		w.Write([]byte(b.String()))
+	case strings.HasPrefix(r.URL.Path, "/abs/"):
+		id := strings.TrimPrefix(r.URL.Path, "/abs/")
+		f.mu.Lock()
+		f.fetched = append(f.fetched, id)
+		f.mu.Unlock()
+		fmt.Fprintf(w, `<meta property="og:title" content="LAMMPS study %s" />`+
+			`<meta property="og:description" content="Simulations with LAMMPS."/>`+
+			`<div class="authors"><span class="descriptor">Authors:</span><a href="#">Alice</a></div>`, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeArxiv) fetchedIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Sorted(slices.Values(f.fetched))
+}
+
+// n 个新格式的 arXiv ID，按新到旧排列
+func arxivIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("2401.%05d", n-i)
+	}
+	return ids
+}
+
+// 使用内存存储、从 fake arXiv 网页抓取的 Handler，已登记软件 LAMMPS
+func newCrawlTestHandler(t *testing.T, arxiv *fakeArxiv) (*Handler, repository.Repositories) {
+	t.Helper()
+	srv := httptest.NewServer(arxiv)
+	t.Cleanup(srv.Close)
+
+	cfg := config.Default()
+	cfg.Crawler.Mailto = "crawler@example.org"
+	cfg.Crawler.RateLimit = 0
+	cfg.Crawler.HostRateLimits = nil
+	cfg.Crawler.RespectRobots = false
+	cfg.Sources.ArxivBackend = "html"
+	cfg.Sources.ArxivWebURL = srv.URL
+	cfg.Sources.ArxivSearchPageSize = arxiv.pageSize
+
+	repos := repository.NewMemory()
+	if err := repos.Software.Create(context.Background(), &models.Software{Name: "LAMMPS"}); err != nil {
+		t.Fatal(err)
+	}
+	return New(repos, &cfg), repos
+}
+
+func TestCrawlArxivAllKeepsSearchOrder(t *testing.T) {
+	arxiv := &fakeArxiv{ids: arxivIDs(60), pageSize: 25}
+	h, _ := newCrawlTestHandler(t, arxiv)
+
+	// 多次检索的顺序都与搜索结果一致，断点恢复才能按位置跳过已处理的论文
+	for range 3 {
+		ids, err := h.crawler.CrawlArxivAll(context.Background(), `"LAMMPS"`, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(ids, arxiv.ids) {
+			t.Fatalf("ids = %v, want %v", ids, arxiv.ids)
+		}
+	}
+
+	// 增量检索翻到上次见过的论文所在的页为止
+	ids, err := h.crawler.CrawlArxivAll(context.Background(), `"LAMMPS"`, arxiv.ids[10])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, arxiv.ids[:25]) {
+		t.Errorf("incremental ids = %v, want first page", ids)
+	}
+}
+
+func TestProcessSoftwarePapersResume(t *testing.T) {
+	arxiv := &fakeArxiv{ids: arxivIDs(60), pageSize: 25}
+	h, repos := newCrawlTestHandler(t, arxiv)
+	ctx := context.Background()
+
+	// 上次处理完了前 10 篇，断点之后的论文都要处理，之前的一篇也不能碰
+	resume := &models.CrawlCheckpoint{Page: 1, LastID: arxiv.ids[9], StartedAt: time.Now().Add(-time.Hour)}
+	var checkpoints []models.CrawlCheckpoint
+	stats, err := h.ProcessSoftwarePapers(ctx, "LAMMPS", crawlOptions{
+		Full:         true,
+		Resume:       resume,
+		OnCheckpoint: func(cp models.CrawlCheckpoint) { checkpoints = append(checkpoints, cp) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := slices.Sorted(slices.Values(arxiv.ids[10:]))
+	if got := arxiv.fetchedIDs(); !slices.Equal(got, want) {
+		t.Errorf("fetched %v, want %v", got, want)
+	}
+	if stats.Found != 60 || stats.Inserted != 50 || stats.Failed != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+	existing, err := repos.Papers.GetExisting(ctx, arxiv.ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := slices.Sorted(maps.Keys(existing))
+	if !slices.Equal(stored, want) {
+		t.Errorf("stored %v, want %v", stored, want)
+	}
+	// 断点接着上次的页数，每页 paperBatchSize 篇
+	if len(checkpoints) != 1 || checkpoints[0].Page != 2 || checkpoints[0].LastID != arxiv.ids[59] {
+		t.Errorf("checkpoints = %+v", checkpoints)
+	}
+}
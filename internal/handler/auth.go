@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/auth"
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
+)
+
+const principalKey = "principal"
+
+// ADMIN_API_KEY 登记在这个用户名下
+const bootstrapAdminName = "admin"
+
+// 解析 Authorization: Bearer <API key 或 JWT>（也接受 X-API-Key 头），凭证有效时把 auth.Principal 写入 context。
+// 没有凭证的请求作为匿名请求继续，由 RequireRole 决定是否放行；凭证无效时直接返回 401
+func (h *Handler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential := c.GetHeader("X-API-Key")
+		if v := c.GetHeader("Authorization"); v != "" {
+			scheme, token, ok := strings.Cut(v, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				c.Error(apperr.New(apperr.Unauthenticated, "authorization header must use the Bearer scheme"))
+				c.Abort()
+				return
+			}
+			credential = strings.TrimSpace(token)
+		}
+		if credential == "" {
+			c.Next()
+			return
+		}
+
+		p, err := h.authenticate(c.Request.Context(), credential)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+// API key 查库校验；JWT 在本地校验签名和有效期后，按用户 ID 重新确认角色和停用状态（结果缓存 userCacheTTL）
+func (h *Handler) authenticate(ctx context.Context, credential string) (*auth.Principal, error) {
+	if auth.IsAPIKey(credential) {
+		user, keyID, err := h.users.Authenticate(ctx, auth.HashAPIKey(credential))
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, apperr.New(apperr.Unauthenticated, "invalid api key")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &auth.Principal{UserID: user.ID, Name: user.Name, Role: user.Role, Method: "api_key", KeyID: keyID}, nil
+	}
+	p, err := h.auth.ParseToken(credential)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.Unauthenticated, "invalid or expired token", err)
+	}
+	user, err := h.tokenUser(ctx, p.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) || (err == nil && user.Disabled) {
+		return nil, apperr.New(apperr.Unauthenticated, "user is disabled or no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// token 中的角色是签发时的快照，以当前角色为准
+	p.Name, p.Role = user.Name, user.Role
+	return p, nil
+}
+
+// JWT 用户的角色和停用状态缓存多久：停用或降级最迟在这段时间后对所有实例生效，
+// 同时避免每个带 JWT 的请求都查库
+const userCacheTTL = 30 * time.Second
+
+// 按用户 ID 缓存的用户，只缓存查到的用户
+type userCache struct {
+	mu    sync.Mutex
+	users map[int]cachedUser
+}
+
+type cachedUser struct {
+	user    models.User
+	expires time.Time
+}
+
+func newUserCache() *userCache {
+	return &userCache{users: make(map[int]cachedUser)}
+}
+
+func (uc *userCache) get(id int) (models.User, bool) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	c, ok := uc.users[id]
+	if !ok || time.Now().After(c.expires) {
+		delete(uc.users, id)
+		return models.User{}, false
+	}
+	return c.user, true
+}
+
+func (uc *userCache) put(u models.User) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.users[u.ID] = cachedUser{user: u, expires: time.Now().Add(userCacheTTL)}
+}
+
+// 本实例修改用户后立即失效，其他实例等缓存过期
+func (uc *userCache) forget(id int) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	delete(uc.users, id)
+}
+
+// JWT 对应的当前用户，先查缓存
+func (h *Handler) tokenUser(ctx context.Context, id int) (models.User, error) {
+	if u, ok := h.userCache.get(id); ok {
+		return u, nil
+	}
+	u, err := h.users.GetByID(ctx, id)
+	if err != nil {
+		return models.User{}, err
+	}
+	h.userCache.put(*u)
+	return *u, nil
+}
+
+// 要求已认证且角色不低于 role，放在 Authenticate 之后
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := principal(c)
+		if p == nil {
+			c.Error(apperr.New(apperr.Unauthenticated, "authentication required"))
+			c.Abort()
+			return
+		}
+		if !models.RoleAtLeast(p.Role, role) {
+			c.Error(apperr.Errorf(apperr.Forbidden, "%s role required", role))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// 当前请求的调用方，匿名请求返回 nil
+func principal(c *gin.Context) *auth.Principal {
+	p, _ := c.Get(principalKey)
+	v, _ := p.(*auth.Principal)
+	return v
+}
+
+// 设置了 ADMIN_API_KEY 时确保 admin 用户和这个 key 存在，用于在没有任何管理员时登录
+func (h *Handler) BootstrapAdmin(ctx context.Context) error {
+	key := h.auth.AdminAPIKey
+	if key == "" {
+		return nil
+	}
+	// config.Validate 已检查过，这里再确认一次，弱 key 不能成为永久的管理员凭证
+	if err := auth.ValidateAPIKey(key); err != nil {
+		return fmt.Errorf("ADMIN_API_KEY: %w", err)
+	}
+
+	user, err := h.users.GetByName(ctx, bootstrapAdminName)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		user = &models.User{Name: bootstrapAdminName, Role: models.RoleAdmin}
+		err = h.users.Create(ctx, user)
+	}
+	if err != nil {
+		return err
+	}
+	if user.Role != models.RoleAdmin || user.Disabled {
+		log.Printf("⚠️ 用户 %s 不是启用状态的管理员，ADMIN_API_KEY 只有 %s 权限", user.Name, user.Role)
+	}
+
+	k := &models.APIKey{UserID: user.ID, Name: "ADMIN_API_KEY", Prefix: auth.APIKeyPrefix(key)}
+	err = h.users.CreateAPIKey(ctx, k, auth.HashAPIKey(key))
+	switch {
+	case errors.Is(err, repository.ErrAPIKeyConflict):
+		// 已登记过（可能已被吊销，吊销后不会重新启用）
+		return nil
+	case err != nil:
+		return err
+	}
+	log.Printf("🔑 已为用户 %s 登记 ADMIN_API_KEY (%s)", user.Name, k.Prefix)
+	return nil
+}
+
+// POST /auth/token 用 API key 换取短期 JWT，之后的请求不再查库校验 key，用户的角色和停用状态仍按缓存确认
+func (h *Handler) IssueToken(c *gin.Context) {
+	p := principal(c)
+	if p.Method != "api_key" {
+		c.Error(apperr.New(apperr.Forbidden, "tokens can only be issued for api keys"))
+		return
+	}
+	user, err := h.users.GetByID(c.Request.Context(), p.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	token, expires, err := h.auth.IssueToken(user)
+	if errors.Is(err, auth.ErrJWTDisabled) {
+		c.Error(apperr.Wrap(apperr.Unavailable, "token issuance is not configured", err))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_at": expires,
+	})
+}
+
+// GET /auth/me 当前调用方
+func (h *Handler) GetCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, principal(c))
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/auth"
+	"hpc-site/internal/config"
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
+)
+
+// 与 main 相同的中间件和路由表，开启认证
+type authServer struct {
+	t      *testing.T
+	h      *Handler
+	repos  repository.Repositories
+	router *gin.Engine
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "0123456789abcdef0123456789abcdef"
+	repos := repository.NewMemory()
+	h := New(repos, &cfg)
+
+	r := gin.New()
+	r.UseRawPath = true
+	r.Use(RequestID(), ErrorHandler(), Recovery(), h.Authenticate())
+	r.NoRoute(NoRoute)
+	h.RegisterRoutes(r)
+	return &authServer{t: t, h: h, repos: repos, router: r}
+}
+
+// 创建用户并为其生成 API key
+func (s *authServer) user(name, role string) (*models.User, string, int) {
+	s.t.Helper()
+	ctx := context.Background()
+	u := &models.User{Name: name, Role: role}
+	if err := s.repos.Users.Create(ctx, u); err != nil {
+		s.t.Fatal(err)
+	}
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	k := &models.APIKey{UserID: u.ID, Name: "test", Prefix: prefix}
+	if err := s.repos.Users.CreateAPIKey(ctx, k, hash); err != nil {
+		s.t.Fatal(err)
+	}
+	return u, key, k.ID
+}
+
+func (s *authServer) token(u *models.User) string {
+	s.t.Helper()
+	token, _, err := s.h.auth.IssueToken(u)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return token
+}
+
+// 以 header 中的凭证发送请求，返回状态码
+func (s *authServer) status(method, path string, header http.Header) int {
+	s.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func bearer(credential string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + credential}}
+}
+
+func denied(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// 公开的查询接口，其余接口都需要认证
+var publicRoutes = map[string]bool{
+	"GET /softwares":               true,
+	"GET /softwares/:id":           true,
+	"GET /softwares/:id/benchmark": true,
+	"GET /papers":                  true,
+	"GET /benchmarks":              true,
+	"GET /search":                  true,
+}
+
+func TestRoutesRequireAuthentication(t *testing.T) {
+	s := newAuthServer(t)
+	routes := s.router.Routes()
+	if len(routes) < 30 {
+		t.Fatalf("only %d routes registered", len(routes))
+	}
+	for _, rt := range routes {
+		if publicRoutes[rt.Method+" "+rt.Path] {
+			continue
+		}
+		path := strings.NewReplacer(":id", "1").Replace(rt.Path)
+		if got := s.status(rt.Method, path, nil); got != http.StatusUnauthorized {
+			t.Errorf("anonymous %s %s: status %d, want 401", rt.Method, rt.Path, got)
+		}
+	}
+}
+
+func TestRoleOrdering(t *testing.T) {
+	for _, tc := range []struct {
+		role, required string
+		want           bool
+	}{
+		{models.RoleViewer, models.RoleViewer, true},
+		{models.RoleViewer, models.RoleCurator, false},
+		{models.RoleViewer, models.RoleAdmin, false},
+		{models.RoleCurator, models.RoleViewer, true},
+		{models.RoleCurator, models.RoleCurator, true},
+		{models.RoleCurator, models.RoleAdmin, false},
+		{models.RoleAdmin, models.RoleViewer, true},
+		{models.RoleAdmin, models.RoleCurator, true},
+		{models.RoleAdmin, models.RoleAdmin, true},
+		{"", models.RoleViewer, false},
+		{"root", models.RoleViewer, false},
+	} {
+		if got := models.RoleAtLeast(tc.role, tc.required); got != tc.want {
+			t.Errorf("RoleAtLeast(%q, %q) = %v, want %v", tc.role, tc.required, got, tc.want)
+		}
+	}
+
+	s := newAuthServer(t)
+	keys := map[string]string{}
+	for _, role := range []string{models.RoleViewer, models.RoleCurator, models.RoleAdmin} {
+		_, keys[role], _ = s.user(role+"-user", role)
+	}
+	// 每组取一个接口：查看审核队列需要 viewer，修改软件需要 curator，抓取全部软件需要 admin
+	for _, tc := range []struct {
+		method, path, required string
+	}{
+		{"GET", "/reviews", models.RoleViewer},
+		{"POST", "/softwares", models.RoleCurator},
+		{"POST", "/softwares/1/crawl", models.RoleCurator},
+		{"POST", "/crawl/all", models.RoleAdmin},
+		{"GET", "/admin/users", models.RoleAdmin},
+	} {
+		for role, key := range keys {
+			got := s.status(tc.method, tc.path, bearer(key))
+			want := models.RoleAtLeast(role, tc.required)
+			if denied(got) == want {
+				t.Errorf("%s %s as %s: status %d, allowed = %v", tc.method, tc.path, role, got, want)
+			}
+			if !want && got != http.StatusForbidden {
+				t.Errorf("%s %s as %s: status %d, want 403", tc.method, tc.path, role, got)
+			}
+		}
+	}
+}
+
+func TestAuthenticateAPIKeys(t *testing.T) {
+	s := newAuthServer(t)
+	ctx := context.Background()
+	_, key, _ := s.user("curator", models.RoleCurator)
+	_, revoked, revokedID := s.user("revoked", models.RoleCurator)
+	if err := s.repos.Users.RevokeAPIKey(ctx, revokedID); err != nil {
+		t.Fatal(err)
+	}
+	disabledUser, disabled, _ := s.user("disabled", models.RoleCurator)
+	disabledUser.Disabled = true
+	if err := s.repos.Users.Update(ctx, disabledUser); err != nil {
+		t.Fatal(err)
+	}
+	unknown, _, _, _ := auth.GenerateAPIKey()
+
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"bearer key", bearer(key), http.StatusOK},
+		{"x-api-key", http.Header{"X-Api-Key": {key}}, http.StatusOK},
+		{"revoked key", bearer(revoked), http.StatusUnauthorized},
+		{"disabled user", bearer(disabled), http.StatusUnauthorized},
+		{"unknown key", bearer(unknown), http.StatusUnauthorized},
+		{"basic scheme", http.Header{"Authorization": {"Basic " + key}}, http.StatusUnauthorized},
+		{"anonymous", nil, http.StatusUnauthorized},
+	} {
+		if got := s.status("GET", "/auth/me", tc.header); got != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAuthenticateTokens(t *testing.T) {
+	s := newAuthServer(t)
+	ctx := context.Background()
+	admin, _, _ := s.user("admin", models.RoleAdmin)
+	demoted, _, _ := s.user("demoted", models.RoleAdmin)
+	disabled, _, _ := s.user("disabled", models.RoleCurator)
+	adminToken, demotedToken, disabledToken := s.token(admin), s.token(demoted), s.token(disabled)
+
+	// 签发之后降级、停用：token 中的角色不再有效
+	demoted.Role = models.RoleViewer
+	disabled.Disabled = true
+	for _, u := range []*models.User{demoted, disabled} {
+		if err := s.repos.Users.Update(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expired := auth.Config{JWTSecret: s.h.auth.JWTSecret, TokenTTL: -time.Minute}
+	expiredToken, _, err := expired.IssueToken(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := auth.Config{JWTSecret: []byte("another-secret-of-32-bytes-long!"), TokenTTL: time.Hour}
+	forgedToken, _, err := forged.IssueToken(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"admin", adminToken, http.StatusOK},
+		{"demoted to viewer", demotedToken, http.StatusForbidden},
+		{"disabled", disabledToken, http.StatusUnauthorized},
+		{"expired", expiredToken, http.StatusUnauthorized},
+		{"wrong secret", forgedToken, http.StatusUnauthorized},
+	} {
+		if got := s.status("GET", "/admin/users", bearer(tc.token)); got != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	// 只能用 API key 换取 token，不能用 token 续期
+	if got := s.status("POST", "/auth/token", bearer(adminToken)); got != http.StatusForbidden {
+		t.Errorf("token refresh: status %d, want 403", got)
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+	weak := newAuthServer(t)
+	weak.h.auth.AdminAPIKey = "hpc_x"
+	if err := weak.h.BootstrapAdmin(ctx); err == nil {
+		t.Error("weak ADMIN_API_KEY accepted")
+	}
+	if users, _ := weak.repos.Users.List(ctx); len(users) != 0 {
+		t.Errorf("users = %+v", users)
+	}
+
+	s := newAuthServer(t)
+	key, _, _, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.h.auth.AdminAPIKey = key
+	// 重启时再次登记同一个 key 不报错
+	for range 2 {
+		if err := s.h.BootstrapAdmin(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := s.status("GET", "/admin/users", bearer(key)); got != http.StatusOK {
+		t.Errorf("bootstrap key: status %d, want 200", got)
+	}
+}
@@ -1,40 +1,137 @@
 package handler
 
 import (
-	"context"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"hpc-site/internal/repository"
+	"hpc-site/internal/models"
 )
 
 // GET /benchmark
-func GetBenchmarks(c *gin.Context) {
-	ctx := context.Background()
-	benchmarks, err := repository.GetAllBenchmarks(ctx)
+func (h *Handler) GetBenchmarks(c *gin.Context) {
+	page, err := parsePageRequest(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取 benchmark 列表失败: " + err.Error()})
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	benchmarks, err := h.benchmarks.List(ctx, page)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, benchmarks)
 }
 
 // GET /software/:id/benchmark
-func GetBenchmarksBySoftware(c *gin.Context) {
-	idStr := c.Param("id")
-	softwareID, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "软件 ID 无效"})
+func (h *Handler) GetBenchmarksBySoftware(c *gin.Context) {
+	softwareID, ok := parseSoftwareID(c)
+	if !ok {
 		return
 	}
 
-	ctx := context.Background()
-	benchmarks, err := repository.GetBenchmarksBySoftwareID(ctx, softwareID)
+	ctx := c.Request.Context()
+	benchmarks, err := h.benchmarks.ListBySoftwareID(ctx, softwareID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取 benchmark 失败: " + err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, benchmarks)
 }
+
+func parseBenchmarkID(c *gin.Context) (int, bool) {
+	id, ok := parseIDParam(c, "invalid benchmark id")
+	return int(id), ok
+}
+
+// 校验 Benchmark 请求体
+func validateBenchmark(b *models.Benchmark) error {
+	b.Name = strings.TrimSpace(b.Name)
+	if b.Name == "" {
+		return fieldError("name", "name is required")
+	}
+	if err := validateHardware(b.Hardware); err != nil {
+		return err
+	}
+	return validateMetrics(b.Metrics)
+}
+
+// POST /softwares/:id/benchmark
+func (h *Handler) CreateBenchmark(c *gin.Context) {
+	softwareID, ok := parseSoftwareID(c)
+	if !ok {
+		return
+	}
+
+	var b models.Benchmark
+	if err := c.ShouldBindJSON(&b); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	b.SoftwareID = softwareID
+	if err := validateBenchmark(&b); err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.benchmarks.Create(ctx, &b); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, b)
+}
+
+// PUT /benchmarks/:id
+func (h *Handler) UpdateBenchmark(c *gin.Context) {
+	id, ok := parseBenchmarkID(c)
+	if !ok {
+		return
+	}
+
+	var b models.Benchmark
+	if err := c.ShouldBindJSON(&b); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	b.ID = id
+
+	ctx := c.Request.Context()
+	// 未指定 software_id 时沿用原值
+	if b.SoftwareID == 0 {
+		existing, err := h.benchmarks.GetByID(ctx, id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		b.SoftwareID = existing.SoftwareID
+	}
+	if err := validateBenchmark(&b); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.benchmarks.Update(ctx, &b); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// DELETE /benchmarks/:id
+func (h *Handler) DeleteBenchmark(c *gin.Context) {
+	id, ok := parseBenchmarkID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.benchmarks.Delete(ctx, id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
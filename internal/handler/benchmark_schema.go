@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// benchmark 的 hardware / metrics 是 JSONB，这里定义服务端强制的结构约束
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindPositiveInt
+	kindNonNegativeInt
+)
+
+type fieldSpec struct {
+	Kind     fieldKind
+	Required bool
+}
+
+// hardware 允许的字段
+var hardwareSchema = map[string]fieldSpec{
+	"cpu_model":    {Kind: kindString, Required: true},
+	"cores":        {Kind: kindPositiveInt, Required: true}, // 每节点核心数
+	"nodes":        {Kind: kindPositiveInt, Required: true},
+	"interconnect": {Kind: kindString},
+	"memory":       {Kind: kindString}, // 如 "256GB"
+	"gpu_model":    {Kind: kindString},
+	"gpus":         {Kind: kindNonNegativeInt}, // 每节点 GPU 数
+}
+
+// metrics 中每一项的字段
+var metricSchema = map[string]fieldSpec{
+	"value": {Required: true}, // 数值，单独校验
+	"unit":  {Kind: kindString, Required: true},
+}
+
+// 校验 hardware 块
+func validateHardware(hw map[string]any) error {
+	if len(hw) == 0 {
+		return fieldError("hardware", "hardware is required")
+	}
+	for _, key := range sortedKeys(hw) {
+		spec, ok := hardwareSchema[key]
+		if !ok {
+			return fieldError("hardware."+key, "hardware.%s is not an allowed field", key)
+		}
+		if err := checkField("hardware."+key, hw[key], spec.Kind); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedKeys(hardwareSchema) {
+		if _, ok := hw[key]; hardwareSchema[key].Required && !ok {
+			return fieldError("hardware."+key, "hardware.%s is required", key)
+		}
+	}
+	return nil
+}
+
+// 校验 metrics 块：{"<name>": {"value": <number>, "unit": "<unit>"}}
+func validateMetrics(metrics map[string]any) error {
+	if len(metrics) == 0 {
+		return fieldError("metrics", "metrics must contain at least one metric")
+	}
+	for _, name := range sortedKeys(metrics) {
+		path := "metrics." + name
+		if strings.TrimSpace(name) == "" {
+			return fieldError("metrics", "metric names must not be empty")
+		}
+		m, ok := metrics[name].(map[string]any)
+		if !ok {
+			return fieldError(path, "%s must be an object with value and unit", path)
+		}
+		for _, key := range sortedKeys(m) {
+			if _, ok := metricSchema[key]; !ok {
+				return fieldError(path+"."+key, "%s.%s is not an allowed field", path, key)
+			}
+		}
+		v, ok := m["value"].(float64)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fieldError(path+".value", "%s.value must be a number", path)
+		}
+		if _, ok := m["unit"]; !ok {
+			return fieldError(path+".unit", "%s.unit is required", path)
+		}
+		if err := checkField(path+".unit", m["unit"], kindString); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkField(path string, v any, kind fieldKind) error {
+	switch kind {
+	case kindString:
+		s, ok := v.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			return fieldError(path, "%s must be a non-empty string", path)
+		}
+	case kindPositiveInt, kindNonNegativeInt:
+		n, ok := v.(float64) // encoding/json 数字默认解析为 float64
+		if !ok || n != math.Trunc(n) {
+			return fieldError(path, "%s must be an integer", path)
+		}
+		if kind == kindPositiveInt && n < 1 {
+			return fieldError(path, "%s must be greater than 0", path)
+		}
+		if kind == kindNonNegativeInt && n < 0 {
+			return fieldError(path, "%s must not be negative", path)
+		}
+	}
+	return nil
+}
+
+// 按字母序遍历，保证错误信息稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,605 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/metrics"
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
+)
+
+const (
+	crawlQueueSize        = 16
+	maxSoftwareErrors     = 20 // 每个软件最多保留的错误条数
+	crawlProgressInterval = 10 // 每处理多少篇论文持久化一次进度
+
+	crawlLockRetryInterval = 10 * time.Second // 其他实例持有抓取锁时，重试获取的间隔
+
+	// 本实例持有的任务每隔 crawlLeaseRenewInterval 续约一次，租约为 crawlLeaseDuration；
+	// 超过租约未续约说明实例已退出，其他实例才会将其任务标记为失败或认领
+	crawlLeaseDuration      = 2 * time.Minute
+	crawlLeaseRenewInterval = 30 * time.Second
+)
+
+// 抓取任务的 advisory lock key，所有实例共用：各实例共享对方站点的限速额度，同一时刻只有一个实例执行抓取任务。
+// 与 crawlScheduleLockKey 不同，定时抓取持有后者等待任务结束，任务本身再获取这把锁
+const crawlJobLockKey int64 = 0x6870632d6a6f6273 // "hpc-jobs"
+
+var (
+	errCrawlQueueFull    = apperr.New(apperr.Unavailable, "crawl job queue is full, retry later")
+	errCrawlShuttingDown = apperr.New(apperr.Unavailable, "server is shutting down, retry later")
+)
+
+// 服务退出时取消任务 ctx 的原因，与用户取消区分：前者保存断点，后者直接结束
+var errCrawlInterrupted = errors.New("crawl interrupted by shutdown")
+
+// 抓取单个软件论文的函数，即 Handler.ProcessSoftwarePapers
+type processSoftwareFunc func(ctx context.Context, softwareName string, opts crawlOptions) (models.CrawlStats, error)
+
+// 抓取任务执行器：任务按提交顺序由单个 worker 串行执行，并通过 crawlJobLockKey 与其他实例互斥，
+// 避免同时对 arXiv 发起多路抓取
+type crawlJobRunner struct {
+	jobs       repository.CrawlJobRepository
+	locks      repository.Locker
+	instanceID string // 本进程持有的任务记录为此 ID
+	lease      time.Duration
+	renewEvery time.Duration
+	queue      chan *models.CrawlJob
+	process    processSoftwareFunc
+	stop       chan struct{} // Shutdown 时关闭
+	stopped    chan struct{} // worker 退出时关闭
+
+	mu       sync.Mutex
+	started  bool
+	stopping bool
+	cancels  map[int64]context.CancelCauseFunc
+	ctxs     map[int64]context.Context
+	dones    map[int64]chan struct{}
+}
+
+func newCrawlJobRunner(jobs repository.CrawlJobRepository, locks repository.Locker, process processSoftwareFunc) *crawlJobRunner {
+	return &crawlJobRunner{
+		jobs:       jobs,
+		locks:      locks,
+		instanceID: newInstanceID(),
+		lease:      crawlLeaseDuration,
+		renewEvery: crawlLeaseRenewInterval,
+		queue:      make(chan *models.CrawlJob, crawlQueueSize),
+		process:    process,
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+		cancels:    make(map[int64]context.CancelCauseFunc),
+		ctxs:       make(map[int64]context.Context),
+		dones:      make(map[int64]chan struct{}),
+	}
+}
+
+// 主机名加随机后缀，同一主机上的多个进程、重启前后的进程互不相同
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "hpc-site"
+	}
+	b := make([]byte, 4)
+	rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
+
+// 启动抓取 worker，应在创建 Handler 之后调用一次。持有实例异常退出、租约已过期的任务标记为失败，
+// 正常退出时中断的任务由本实例认领并重新入队，从断点继续；其他实例仍在执行的任务不受影响
+func (h *Handler) StartCrawlWorker() {
+	h.crawlJobs.Start()
+}
+
+func (r *crawlJobRunner) Start() {
+	ctx := context.Background()
+	r.failExpired()
+
+	r.mu.Lock()
+	r.started = true
+	r.mu.Unlock()
+	go r.loop()
+	go r.heartbeat()
+
+	jobs, err := r.jobs.ClaimInterrupted(ctx, r.instanceID, r.lease, crawlQueueSize)
+	if err != nil {
+		log.Printf("查询被中断的抓取任务失败: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		if err := r.enqueue(job); err != nil {
+			log.Printf("[job %d] 恢复抓取任务失败: %v", job.ID, err)
+			continue
+		}
+		log.Printf("[job %d] 恢复上次中断的抓取任务", job.ID)
+	}
+}
+
+// 停止抓取：定时抓取不再触发，运行中的任务处理完当前页后保存断点并标记为 interrupted，
+// 排队中的任务也标记为 interrupted，下次启动时由 StartCrawlWorker 继续。ctx 到期前未停止时返回 ctx 的错误
+func (h *Handler) StopCrawling(ctx context.Context) error {
+	var cronDone context.Context
+	if h.crawlSchedule.cron != nil {
+		cronDone = h.crawlSchedule.cron.Stop()
+	}
+	if err := h.crawlJobs.Shutdown(ctx); err != nil {
+		return err
+	}
+	// 正在执行的定时抓取等待任务结束后记录结果，任务已中断，这里很快返回
+	if cronDone != nil {
+		select {
+		case <-cronDone.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// 创建任务并放入队列，返回任务 ID；入队后任务对象归 worker 所有。
+// full 为 true 时忽略各软件上次的抓取状态，重新抓取全部结果
+func (r *crawlJobRunner) Submit(ctx context.Context, softwares []string, full bool) (int64, error) {
+	job := &models.CrawlJob{State: models.CrawlJobQueued, Full: full}
+	job.Softwares = make([]models.CrawlSoftwareProgress, 0, len(softwares))
+	for _, name := range softwares {
+		job.Softwares = append(job.Softwares, models.CrawlSoftwareProgress{Software: name, State: models.CrawlJobQueued})
+	}
+	r.mu.Lock()
+	stopping := r.stopping
+	r.mu.Unlock()
+	if stopping {
+		return 0, errCrawlShuttingDown
+	}
+	if len(r.queue) == cap(r.queue) {
+		return 0, errCrawlQueueFull
+	}
+	job.InstanceID = r.instanceID
+	if err := r.jobs.Create(ctx, job, r.lease); err != nil {
+		return 0, err
+	}
+
+	err := r.enqueue(job)
+	switch {
+	case errors.Is(err, errCrawlShuttingDown):
+		// 创建任务期间开始退出，留到下次启动时执行
+		job.State = models.CrawlJobInterrupted
+		r.save(job)
+		return job.ID, nil
+	case err != nil:
+		job.State = models.CrawlJobFailed
+		job.Error = crawlErrorMessage(err)
+		r.finish(job)
+		return 0, err
+	}
+	return job.ID, nil
+}
+
+// 登记任务的 ctx 并放入队列；与 Shutdown 互斥，保证退出时队列里的任务都会被标记为 interrupted
+func (r *crawlJobRunner) enqueue(job *models.CrawlJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopping {
+		return errCrawlShuttingDown
+	}
+
+	jobCtx, cancel := context.WithCancelCause(context.Background())
+	select {
+	case r.queue <- job:
+		r.cancels[job.ID] = cancel
+		r.ctxs[job.ID] = jobCtx
+		r.dones[job.ID] = make(chan struct{})
+		return nil
+	default:
+		cancel(nil)
+		return errCrawlQueueFull
+	}
+}
+
+// 取消任务，任务不在本进程中执行时返回 false
+func (r *crawlJobRunner) Cancel(id int64) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[id]
+	r.mu.Unlock()
+	if ok {
+		cancel(nil)
+	}
+	return ok
+}
+
+// 中断全部任务并等待 worker 退出，之后提交的任务返回 errCrawlShuttingDown
+func (r *crawlJobRunner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if r.stopping {
+		r.mu.Unlock()
+		return nil
+	}
+	r.stopping = true
+	started := r.started
+	for _, cancel := range r.cancels {
+		cancel(errCrawlInterrupted)
+	}
+	r.mu.Unlock()
+	close(r.stop)
+
+	if !started {
+		return nil
+	}
+	select {
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 返回任务结束时关闭的 channel，任务不在本进程中时返回已关闭的 channel
+func (r *crawlJobRunner) Done(id int64) <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if done, ok := r.dones[id]; ok {
+		return done
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+func (r *crawlJobRunner) forget(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.cancels[id]; ok {
+		cancel(nil)
+	}
+	if done, ok := r.dones[id]; ok {
+		close(done)
+	}
+	delete(r.cancels, id)
+	delete(r.ctxs, id)
+	delete(r.dones, id)
+}
+
+// 定期续约本实例持有的任务，并清理其他实例异常退出后留下的过期任务。
+// worker 退出后才停止，退出期间运行中的任务仍持有租约，中断后租约随之释放
+func (r *crawlJobRunner) heartbeat() {
+	ticker := time.NewTicker(r.renewEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopped:
+			return
+		case <-ticker.C:
+		}
+		if _, err := r.jobs.RenewLeases(context.Background(), r.instanceID, r.lease); err != nil {
+			log.Printf("续约抓取任务失败: %v", err)
+		}
+		r.failExpired()
+	}
+}
+
+func (r *crawlJobRunner) failExpired() {
+	if n, err := r.jobs.FailExpired(context.Background()); err != nil {
+		log.Printf("清理租约过期的抓取任务失败: %v", err)
+	} else if n > 0 {
+		log.Printf("已将 %d 个租约过期的抓取任务标记为失败", n)
+	}
+}
+
+func (r *crawlJobRunner) loop() {
+	defer close(r.stopped)
+	for {
+		// 优先响应退出，避免在两个 case 都就绪时又开始新任务
+		select {
+		case <-r.stop:
+			r.interruptQueued()
+			return
+		default:
+		}
+
+		select {
+		case <-r.stop:
+			r.interruptQueued()
+			return
+		case job := <-r.queue:
+			r.mu.Lock()
+			ctx := r.ctxs[job.ID]
+			r.mu.Unlock()
+
+			r.run(ctx, job)
+			r.forget(job.ID)
+		}
+	}
+}
+
+// 退出时把仍在队列中的任务标记为 interrupted
+func (r *crawlJobRunner) interruptQueued() {
+	for {
+		select {
+		case job := <-r.queue:
+			if job.State == models.CrawlJobQueued {
+				job.State = models.CrawlJobInterrupted
+				r.save(job)
+				log.Printf("[job %d] 服务退出，排队中的任务将在下次启动时执行", job.ID)
+			}
+			r.forget(job.ID)
+		default:
+			return
+		}
+	}
+}
+
+func (r *crawlJobRunner) run(ctx context.Context, job *models.CrawlJob) {
+	interrupted := func() bool { return errors.Is(context.Cause(ctx), errCrawlInterrupted) }
+
+	r.checkCancelRequested(job.ID)
+	if release, err := r.lock(ctx, job.ID); err == nil {
+		defer release()
+	}
+	// 排队或等待抓取锁期间已被取消
+	if ctx.Err() != nil {
+		if interrupted() {
+			job.State = models.CrawlJobInterrupted
+			r.save(job)
+			return
+		}
+		job.State = models.CrawlJobCancelled
+		r.finish(job)
+		return
+	}
+
+	// 耗时指标只统计本次运行，恢复的任务不包含上次退出前的部分
+	runStart := time.Now()
+	job.State = models.CrawlJobRunning
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	r.save(job)
+
+	for i := range job.Softwares {
+		sp := &job.Softwares[i]
+		// 恢复的任务跳过已结束的软件
+		if sp.State != models.CrawlJobQueued && sp.State != models.CrawlJobInterrupted {
+			continue
+		}
+		r.checkCancelRequested(job.ID)
+		if ctx.Err() != nil {
+			if !interrupted() {
+				sp.State = models.CrawlJobCancelled
+			}
+			continue
+		}
+
+		resume := sp.Checkpoint
+		if resume != nil {
+			log.Printf("[job %d] 从第 %d 页继续抓取 %s 相关的论文", job.ID, resume.Page+1, sp.Software)
+		} else {
+			log.Printf("[job %d] 开始抓取 %s 相关的论文", job.ID, sp.Software)
+		}
+		sp.State = models.CrawlJobRunning
+		r.save(job)
+		softwareStart := time.Now()
+
+		// 恢复时接着断点处的计数累加，found 以本次检索为准。断点之后没处理完的那一页会重做，
+		// 中断前已经计入 sp.CrawlStats 的部分不能再算一次
+		var base models.CrawlStats
+		if resume != nil {
+			base = resume.Stats
+		}
+		merge := func(s models.CrawlStats) models.CrawlStats {
+			merged := base
+			merged.Add(s)
+			if s.Found > 0 {
+				merged.Found = s.Found
+			}
+			return merged
+		}
+		processed := 0
+		stats, err := r.process(ctx, sp.Software, crawlOptions{
+			Full:   job.Full,
+			Resume: resume,
+			OnPaper: func(s models.CrawlStats, paperErr error) {
+				sp.CrawlStats = merge(s)
+				if paperErr != nil && len(sp.Errors) < maxSoftwareErrors {
+					sp.Errors = append(sp.Errors, crawlErrorMessage(paperErr))
+				}
+				processed++
+				if processed%crawlProgressInterval == 0 {
+					r.save(job)
+				}
+			},
+			OnCheckpoint: func(cp models.CrawlCheckpoint) {
+				// 一页处理完时 OnPaper 已汇报了这一页的全部论文，计数与断点一起保存
+				cp.Stats = sp.CrawlStats
+				sp.Checkpoint = &cp
+				r.save(job)
+				r.checkCancelRequested(job.ID)
+			},
+		})
+		sp.CrawlStats = merge(stats)
+
+		switch {
+		case ctx.Err() != nil && interrupted():
+			sp.State = models.CrawlJobInterrupted
+			log.Printf("[job %d] %s 的抓取被中断，已保存断点: %+v", job.ID, sp.Software, sp.Checkpoint)
+		case ctx.Err() != nil:
+			sp.State = models.CrawlJobCancelled
+			sp.Checkpoint = nil
+		case err != nil:
+			sp.State = models.CrawlJobFailed
+			sp.Errors = append(sp.Errors, crawlErrorMessage(err))
+			log.Printf("[job %d] 抓取 %s 失败: %v", job.ID, sp.Software, err)
+			sp.Checkpoint = nil
+		default:
+			sp.State = models.CrawlJobSucceeded
+			sp.Checkpoint = nil
+		}
+		metrics.CrawlerSoftwareDuration.WithLabelValues(sp.State).Observe(time.Since(softwareStart).Seconds())
+		job.CrawlStats = totalStats(job.Softwares)
+		r.save(job)
+	}
+
+	switch {
+	case ctx.Err() != nil && interrupted():
+		job.State = models.CrawlJobInterrupted
+		r.save(job)
+		metrics.CrawlerJobDuration.WithLabelValues(job.State).Observe(time.Since(runStart).Seconds())
+		log.Printf("[job %d] 服务退出，抓取任务将在下次启动时继续", job.ID)
+		return
+	case ctx.Err() != nil:
+		job.State = models.CrawlJobCancelled
+	case allSoftwareFailed(job):
+		job.State = models.CrawlJobFailed
+		job.Error = "crawl failed for every software"
+	default:
+		job.State = models.CrawlJobSucceeded
+	}
+	r.finish(job)
+	metrics.CrawlerJobDuration.WithLabelValues(job.State).Observe(time.Since(runStart).Seconds())
+	log.Printf("[job %d] 抓取任务结束: %s", job.ID, job.State)
+}
+
+// 等待抓取锁，ctx 结束时返回其错误。锁随数据库连接释放，持有锁的实例异常退出后其他实例可以接着执行
+func (r *crawlJobRunner) lock(ctx context.Context, id int64) (release func(), err error) {
+	waiting := false
+	for {
+		release, ok, err := r.locks.TryLock(ctx, crawlJobLockKey)
+		switch {
+		case ok:
+			return release, nil
+		case err != nil && ctx.Err() == nil:
+			log.Printf("[job %d] 获取抓取锁失败，稍后重试: %v", id, err)
+		case err == nil && !waiting:
+			log.Printf("[job %d] 其他实例正在执行抓取任务，等待其结束", id)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(crawlLockRetryInterval):
+		}
+		r.checkCancelRequested(id)
+	}
+}
+
+// 取消请求可能由其他实例受理，只记录在数据库中；已请求时取消本进程中的任务
+func (r *crawlJobRunner) checkCancelRequested(id int64) {
+	requested, err := r.jobs.CancelRequested(context.Background(), id)
+	if err != nil {
+		log.Printf("[job %d] 查询取消请求失败: %v", id, err)
+		return
+	}
+	if requested && r.Cancel(id) {
+		log.Printf("[job %d] 已请求取消，停止抓取", id)
+	}
+}
+
+// 写入任务记录、对外展示的错误信息：领域错误取其 code 和 message，其余错误只给出类别。
+// 原始错误可能含数据库错误、上游 URL 和联系邮箱，由调用方写日志
+func crawlErrorMessage(err error) string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var msgs []string
+		for _, e := range joined.Unwrap() {
+			msgs = append(msgs, crawlErrorMessage(e))
+		}
+		return strings.Join(msgs, "; ")
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case repository.IsTimeout(context.Background(), err):
+		return string(apperr.Timeout) + ": timed out"
+	}
+	if e, ok := apperr.From(err); ok {
+		return string(e.Code) + ": " + e.Message
+	}
+	return string(apperr.Internal) + ": internal error"
+}
+
+// 各软件计数之和
+func totalStats(softwares []models.CrawlSoftwareProgress) models.CrawlStats {
+	var total models.CrawlStats
+	for _, sp := range softwares {
+		total.Add(sp.CrawlStats)
+	}
+	return total
+}
+
+func allSoftwareFailed(job *models.CrawlJob) bool {
+	if len(job.Softwares) == 0 {
+		return false
+	}
+	for _, sp := range job.Softwares {
+		if sp.State != models.CrawlJobFailed {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *crawlJobRunner) finish(job *models.CrawlJob) {
+	now := time.Now()
+	job.FinishedAt = &now
+	r.save(job)
+}
+
+// 持久化任务进度；任务 ctx 可能已取消，这里单独使用后台 context。
+// 任务已不归本实例持有时（租约过期后被其他实例处理，或排队时已被取消）停止执行
+func (r *crawlJobRunner) save(job *models.CrawlJob) {
+	err := r.jobs.Update(context.Background(), job)
+	switch {
+	case errors.Is(err, repository.ErrCrawlJobLeaseLost):
+		if !job.Finished() && r.Cancel(job.ID) {
+			log.Printf("[job %d] 任务已不归本实例持有，停止抓取", job.ID)
+		}
+	case err != nil:
+		log.Printf("[job %d] 保存抓取任务进度失败: %v", job.ID, err)
+	}
+}
+
+// GET /crawl/jobs/:id
+func (h *Handler) GetCrawlJob(c *gin.Context) {
+	id, ok := parseIDParam(c, "invalid crawl job id")
+	if !ok {
+		return
+	}
+
+	job, err := h.crawlJobs.jobs.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DELETE /crawl/jobs/:id 取消排队中、运行中或被中断的任务，可由任意实例受理：
+// 取消请求记录在数据库中，执行任务的实例处理完当前页后结束任务
+func (h *Handler) CancelCrawlJob(c *gin.Context) {
+	id, ok := parseIDParam(c, "invalid crawl job id")
+	if !ok {
+		return
+	}
+
+	job, err := h.crawlJobs.jobs.RequestCancel(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrCrawlJobFinished) {
+		c.Error(repository.ErrCrawlJobFinished.WithDetails(gin.H{"job": job}))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// 任务在本实例中时立即取消，不必等到下一页
+	h.crawlJobs.Cancel(id)
+	c.JSON(http.StatusAccepted, gin.H{"message": "已请求取消抓取任务", "job_id": id, "state": job.State})
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
+)
+
+// 基于内存存储的抓取任务执行器，process 代替真实的抓取；测试结束时退出 worker
+func newTestRunner(t *testing.T, jobs repository.CrawlJobRepository, lease, renewEvery time.Duration, process processSoftwareFunc) *crawlJobRunner {
+	t.Helper()
+	r := newCrawlJobRunner(jobs, repository.NewMemory().Locks, process)
+	r.lease = lease
+	r.renewEvery = renewEvery
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.Shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+	return r
+}
+
+// 在 timeout 内等待条件成立
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func getJob(t *testing.T, jobs repository.CrawlJobRepository, id int64) *models.CrawlJob {
+	t.Helper()
+	job, err := jobs.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// 阻塞到 release 关闭或任务被取消的 process
+func blockingProcess(started chan<- struct{}, release <-chan struct{}) processSoftwareFunc {
+	return func(ctx context.Context, _ string, _ crawlOptions) (models.CrawlStats, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return models.CrawlStats{Found: 1, Inserted: 1}, nil
+		case <-ctx.Done():
+			return models.CrawlStats{}, ctx.Err()
+		}
+	}
+}
+
+func TestCrawlJobHeartbeatRenewsLease(t *testing.T) {
+	jobs := repository.NewMemory().CrawlJobs
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	r := newTestRunner(t, jobs, 100*time.Millisecond, 10*time.Millisecond, blockingProcess(started, release))
+	r.Start()
+
+	id, err := r.Submit(context.Background(), []string{"LAMMPS"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	job := getJob(t, jobs, id)
+	if job.InstanceID != r.instanceID || job.State != models.CrawlJobRunning || job.LeaseExpiresAt == nil {
+		t.Fatalf("job = %+v", job)
+	}
+
+	// 运行时间超过租约的数倍，心跳一直续约，其他实例不会把它当作已退出
+	time.Sleep(300 * time.Millisecond)
+	if n, err := jobs.FailExpired(context.Background()); err != nil || n != 0 {
+		t.Fatalf("FailExpired = %d, %v", n, err)
+	}
+	job = getJob(t, jobs, id)
+	if job.State != models.CrawlJobRunning || !job.LeaseExpiresAt.After(time.Now()) {
+		t.Fatalf("job = %+v", job)
+	}
+
+	close(release)
+	<-r.Done(id)
+	job = getJob(t, jobs, id)
+	if job.State != models.CrawlJobSucceeded || job.LeaseExpiresAt != nil || job.Inserted != 1 {
+		t.Errorf("finished job = %+v", job)
+	}
+}
+
+func TestCrawlJobStartFailsExpiredJobs(t *testing.T) {
+	jobs := repository.NewMemory().CrawlJobs
+	ctx := context.Background()
+
+	// 其他实例异常退出，租约已过期的运行中任务（其中一个已请求取消）；以及仍在续约的任务
+	dead := &models.CrawlJob{State: models.CrawlJobRunning, InstanceID: "dead"}
+	alive := &models.CrawlJob{State: models.CrawlJobRunning, InstanceID: "alive"}
+	cancelled := &models.CrawlJob{State: models.CrawlJobRunning, InstanceID: "dead"}
+	for _, job := range []*models.CrawlJob{dead, alive, cancelled} {
+		lease := -time.Second
+		if job == alive {
+			lease = time.Hour
+		}
+		if err := jobs.Create(ctx, job, lease); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := jobs.RequestCancel(ctx, cancelled.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRunner(t, jobs, time.Minute, time.Hour, nil)
+	r.Start()
+
+	if job := getJob(t, jobs, dead.ID); job.State != models.CrawlJobFailed || job.Error == "" || job.FinishedAt == nil {
+		t.Errorf("dead job = %+v", job)
+	}
+	if job := getJob(t, jobs, alive.ID); job.State != models.CrawlJobRunning {
+		t.Errorf("alive job = %+v", job)
+	}
+	if job := getJob(t, jobs, cancelled.ID); job.State != models.CrawlJobCancelled {
+		t.Errorf("cancelled job = %+v", job)
+	}
+}
+
+func TestCrawlJobStopsWhenLeaseLost(t *testing.T) {
+	jobs := repository.NewMemory().CrawlJobs
+	started, proceed := make(chan struct{}), make(chan struct{})
+	stopped := make(chan error, 1)
+	process := func(ctx context.Context, _ string, opts crawlOptions) (models.CrawlStats, error) {
+		close(started)
+		<-proceed
+		// 保存进度时发现任务已被其他实例处理，本实例应随即停止
+		opts.OnCheckpoint(models.CrawlCheckpoint{Page: 1, LastID: "2401.00001"})
+		select {
+		case <-ctx.Done():
+			stopped <- ctx.Err()
+			return models.CrawlStats{}, ctx.Err()
+		case <-time.After(5 * time.Second):
+			stopped <- nil
+			return models.CrawlStats{}, nil
+		}
+	}
+	// 不续约：模拟本实例卡住，租约过期后被其他实例标记为失败
+	r := newTestRunner(t, jobs, 50*time.Millisecond, time.Hour, process)
+	r.Start()
+
+	id, err := r.Submit(context.Background(), []string{"LAMMPS"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	waitFor(t, time.Second, "lease to expire", func() bool {
+		n, err := jobs.FailExpired(context.Background())
+		return err == nil && n == 1
+	})
+	close(proceed)
+
+	if err := <-stopped; err == nil {
+		t.Fatal("job kept running after losing its lease")
+	}
+	<-r.Done(id)
+	// 本实例结束时不能覆盖其他实例写入的结果
+	if job := getJob(t, jobs, id); job.State != models.CrawlJobFailed || job.Softwares[0].Checkpoint != nil {
+		t.Errorf("job = %+v", job)
+	}
+}
+
+func TestCrawlJobResumeCountsFromCheckpoint(t *testing.T) {
+	jobs := repository.NewMemory().CrawlJobs
+	ctx := context.Background()
+
+	// 上次处理完第 1 页（10 篇）后，第 2 页处理到一半时服务退出：
+	// 已保存的计数包含第 2 页的 5 篇，恢复后第 2 页会整页重做
+	checkpoint := &models.CrawlCheckpoint{
+		Page: 1, LastID: "2401.00050", StartedAt: time.Now().Add(-time.Hour),
+		Stats: models.CrawlStats{Found: 30, Inserted: 8, Skipped: 2},
+	}
+	job := &models.CrawlJob{
+		State:      models.CrawlJobInterrupted,
+		InstanceID: "old",
+		Softwares: []models.CrawlSoftwareProgress{
+			{Software: "GROMACS", State: models.CrawlJobSucceeded, CrawlStats: models.CrawlStats{Found: 3, Inserted: 3}},
+			{Software: "LAMMPS", State: models.CrawlJobInterrupted, Checkpoint: checkpoint,
+				CrawlStats: models.CrawlStats{Found: 30, Inserted: 12, Skipped: 3}},
+		},
+	}
+	if err := jobs.Create(ctx, job, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	var resumed *models.CrawlCheckpoint
+	process := func(ctx context.Context, name string, opts crawlOptions) (models.CrawlStats, error) {
+		calls = append(calls, name)
+		resumed = opts.Resume
+		// 重做的第 2 页和剩下的第 3 页，共 20 篇
+		stats := models.CrawlStats{Found: 30}
+		for i := range 20 {
+			if i%10 == 0 {
+				stats.Skipped++
+			} else {
+				stats.Inserted++
+			}
+			opts.OnPaper(stats, nil)
+		}
+		return stats, nil
+	}
+	r := newTestRunner(t, jobs, time.Minute, time.Hour, process)
+	r.Start()
+	<-r.Done(job.ID)
+
+	if len(calls) != 1 || calls[0] != "LAMMPS" {
+		t.Fatalf("processed %v, want only LAMMPS", calls)
+	}
+	if resumed == nil || resumed.LastID != checkpoint.LastID || resumed.Page != 1 {
+		t.Errorf("resume = %+v", resumed)
+	}
+	got := getJob(t, jobs, job.ID)
+	if got.State != models.CrawlJobSucceeded || got.InstanceID != r.instanceID {
+		t.Fatalf("job = %+v", got)
+	}
+	want := models.CrawlStats{Found: 30, Inserted: 8 + 18, Skipped: 2 + 2}
+	if sp := got.Softwares[1]; sp.CrawlStats != want || sp.Checkpoint != nil {
+		t.Errorf("LAMMPS = %+v, want %+v", sp, want)
+	}
+	if want.Add(got.Softwares[0].CrawlStats); got.CrawlStats != want {
+		t.Errorf("job stats = %+v, want %+v", got.CrawlStats, want)
+	}
+}
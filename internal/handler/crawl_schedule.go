@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+	"hpc-site/internal/models"
+)
+
+// 定时抓取的 advisory lock key，所有实例共用，保证同一时刻只有一个实例在执行定时抓取
+const crawlScheduleLockKey int64 = 0x6870632d6372776c // "hpc-crwl"
+
+// 定时抓取调度器，crawler.schedule 为空时不启用
+type crawlSchedule struct {
+	spec  string
+	cron  *cron.Cron
+	entry cron.EntryID
+}
+
+// 按 crawler.schedule（标准 5 段 cron 表达式或 @daily 等描述符，可用 CRON_TZ= 前缀指定时区）
+// 定时增量抓取全部软件的论文，应在 StartCrawlWorker 之后调用一次
+func (h *Handler) StartCrawlScheduler() {
+	spec := h.crawlSchedule.spec
+	if spec == "" {
+		log.Println("未设置 CRAWL_SCHEDULE，不启用定时抓取")
+		return
+	}
+
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
+	entry, err := c.AddFunc(spec, h.runScheduledCrawl)
+	if err != nil {
+		log.Fatalf("❌ CRAWL_SCHEDULE %q 无效: %v", spec, err)
+	}
+	h.crawlSchedule = crawlSchedule{spec: spec, cron: c, entry: entry}
+
+	h.failInterruptedCrawlRuns()
+	c.Start()
+	log.Printf("⏰ 已启用定时抓取 %q，下次执行: %s", spec, c.Entry(entry).Next.Format(time.RFC3339))
+}
+
+// 上次执行到一半时进程退出的记录标记为失败；拿不到锁说明有实例正在执行，那条记录不能动
+func (h *Handler) failInterruptedCrawlRuns() {
+	ctx := context.Background()
+	release, ok, err := h.locks.TryLock(ctx, crawlScheduleLockKey)
+	if err != nil || !ok {
+		return
+	}
+	defer release()
+	if n, err := h.crawlRuns.FailUnfinished(ctx); err != nil {
+		log.Printf("清理未完成的定时抓取记录失败: %v", err)
+	} else if n > 0 {
+		log.Printf("已将 %d 条上次未完成的定时抓取记录标记为失败", n)
+	}
+}
+
+// 执行一次定时抓取：持有 advisory lock 直到抓取任务结束，其他实例在此期间跳过
+func (h *Handler) runScheduledCrawl() {
+	ctx := context.Background()
+	release, ok, err := h.locks.TryLock(ctx, crawlScheduleLockKey)
+	if err != nil {
+		log.Printf("获取定时抓取锁失败: %v", err)
+		return
+	}
+	if !ok {
+		log.Println("其他实例正在执行定时抓取，跳过本次")
+		return
+	}
+	defer release()
+
+	run := &models.CrawlRun{State: models.CrawlJobRunning}
+	if err := h.crawlRuns.Create(ctx, run); err != nil {
+		log.Printf("创建定时抓取记录失败: %v", err)
+		return
+	}
+	log.Printf("⏰ 开始定时抓取 [run %d]", run.ID)
+
+	jobID, err := h.submitAllSoftwareCrawl(ctx, false)
+	if err != nil {
+		log.Printf("[run %d] 提交定时抓取任务失败: %v", run.ID, err)
+		run.State = models.CrawlJobFailed
+		run.Error = crawlErrorMessage(err)
+		h.finishCrawlRun(run)
+		return
+	}
+	run.JobID = &jobID
+	if err := h.crawlRuns.Update(ctx, run); err != nil {
+		log.Printf("[run %d] 保存定时抓取记录失败: %v", run.ID, err)
+	}
+
+	<-h.crawlJobs.Done(jobID)
+
+	job, err := h.crawlJobs.jobs.GetByID(ctx, jobID)
+	if err != nil {
+		log.Printf("[run %d] 查询抓取任务 %d 失败: %v", run.ID, jobID, err)
+		run.State = models.CrawlJobFailed
+		run.Error = "query crawl job: " + crawlErrorMessage(err)
+	} else {
+		run.State = job.State
+		run.Error = job.Error
+	}
+	h.finishCrawlRun(run)
+}
+
+func (h *Handler) finishCrawlRun(run *models.CrawlRun) {
+	now := time.Now()
+	run.FinishedAt = &now
+	if err := h.crawlRuns.Update(context.Background(), run); err != nil {
+		log.Printf("[run %d] 保存定时抓取记录失败: %v", run.ID, err)
+	}
+	log.Printf("⏰ 定时抓取结束 [run %d]: %s", run.ID, run.State)
+}
+
+// GET /crawl/schedule 定时抓取配置、下次执行时间、最近一次和最近一次成功的执行
+func (h *Handler) GetCrawlSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+	resp := gin.H{"enabled": h.crawlSchedule.cron != nil}
+	if h.crawlSchedule.cron != nil {
+		resp["schedule"] = h.crawlSchedule.spec
+		resp["next_run"] = h.crawlSchedule.cron.Entry(h.crawlSchedule.entry).Next
+	}
+
+	for key, state := range map[string]string{"last_run": "", "last_success": models.CrawlJobSucceeded} {
+		run, err := h.crawlRuns.GetLatest(ctx, state)
+		if errors.Is(err, sql.ErrNoRows) {
+			resp[key] = nil
+			continue
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp[key] = run
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /crawl/runs 定时抓取执行记录（分页）
+func (h *Handler) GetCrawlRuns(c *gin.Context) {
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	runs, err := h.crawlRuns.Query(c.Request.Context(), page)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/repository"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// 调用方传入的请求 ID 只接受这些字符，避免把任意内容写进日志和响应头
+var requestIDRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// 统一的错误响应
+type errorResponse struct {
+	Code      apperr.Code `json:"code"`
+	Message   string      `json:"message"`
+	Details   any         `json:"details"`
+	RequestID string      `json:"request_id"`
+}
+
+var errorStatus = map[apperr.Code]int{
+	apperr.NotFound:        http.StatusNotFound,
+	apperr.Conflict:        http.StatusConflict,
+	apperr.Validation:      http.StatusBadRequest,
+	apperr.Unauthenticated: http.StatusUnauthorized,
+	apperr.Forbidden:       http.StatusForbidden,
+	apperr.Unavailable:     http.StatusServiceUnavailable,
+	apperr.Timeout:         http.StatusGatewayTimeout,
+	apperr.Internal:        http.StatusInternalServerError,
+}
+
+// 为每个请求分配 ID（沿用请求头中合法的 X-Request-ID），写入响应头并供错误响应和日志使用
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !requestIDRe.MatchString(id) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// 处理函数通过 c.Error 返回错误，这里统一转换为状态码和 errorResponse；
+// 领域错误以外的错误（包括数据库错误）只返回 internal，原始错误只写日志
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		e := toAppError(c.Request.Context(), err)
+		status := errorStatus[e.Code]
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		requestID := c.GetString(requestIDKey)
+		if status >= http.StatusInternalServerError {
+			log.Printf("❌ [%s] %s %s %d: %v", requestID, c.Request.Method, c.Request.URL.Path, status, err)
+		}
+		if status == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `Bearer realm="hpc-site"`)
+		}
+		c.JSON(status, errorResponse{
+			Code:      e.Code,
+			Message:   e.Message,
+			Details:   e.Details,
+			RequestID: requestID,
+		})
+	}
+}
+
+// panic 时记录为内部错误，由 ErrorHandler 返回统一的 500 响应
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		c.Error(fmt.Errorf("panic: %v", recovered))
+		c.Abort()
+	})
+}
+
+// 未注册的路由同样返回统一的错误响应
+func NoRoute(c *gin.Context) {
+	c.Error(apperr.New(apperr.NotFound, "route not found"))
+}
+
+// 超时优先于错误本身的类别（如抓取上游时超时），未分类的错误视为内部错误。
+// 客户端断开导致的查询取消不算超时
+func toAppError(ctx context.Context, err error) *apperr.Error {
+	if repository.IsTimeout(ctx, err) {
+		return apperr.Wrap(apperr.Timeout, "request timed out", err)
+	}
+	if e, ok := apperr.From(err); ok {
+		return e
+	}
+	return apperr.Wrap(apperr.Internal, "internal server error", err)
+}
+
+// 请求体无法解析为 JSON 时的错误
+func invalidBody(err error) error {
+	return apperr.Wrap(apperr.Validation, "invalid request body", err)
+}
+
+// 某个字段校验失败，details 中给出字段名
+func fieldError(field, format string, args ...any) error {
+	return apperr.Errorf(apperr.Validation, format, args...).WithDetails(map[string]string{"field": field})
+}
+
+// 解析路径参数 :id（正整数），无效时记录 Validation 错误并返回 false
+func parseIDParam(c *gin.Context, message string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.Error(apperr.New(apperr.Validation, message).WithDetails(map[string]string{"field": "id"}))
+		return 0, false
+	}
+	return id, true
+}
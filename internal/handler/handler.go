@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"hpc-site/internal/auth"
+	"hpc-site/internal/config"
+	"hpc-site/internal/repository"
+)
+
+// HTTP 接口和抓取任务共用的依赖，各存储通过 New 注入，其余按配置创建
+type Handler struct {
+	software   repository.SoftwareRepository
+	papers     repository.PaperRepository
+	benchmarks repository.BenchmarkRepository
+	users      repository.UserRepository
+	reviews    repository.ReviewRepository
+	search     repository.SearchRepository
+	crawlRuns  repository.CrawlRunRepository
+	crawlState repository.CrawlStateRepository
+	locks      repository.Locker
+	auth       auth.Config
+	userCache  *userCache // JWT 用户的当前角色和停用状态
+	crawler    *crawler
+
+	crawlJobs     *crawlJobRunner
+	crawlSchedule crawlSchedule
+}
+
+func New(repos repository.Repositories, cfg *config.Config) *Handler {
+	h := &Handler{
+		software:   repos.Software,
+		papers:     repos.Papers,
+		benchmarks: repos.Benchmarks,
+		users:      repos.Users,
+		reviews:    repos.Reviews,
+		search:     repos.Search,
+		crawlRuns:  repos.CrawlRuns,
+		crawlState: repos.CrawlState,
+		locks:      repos.Locks,
+		auth: auth.Config{
+			JWTSecret:   []byte(cfg.Auth.JWTSecret),
+			TokenTTL:    cfg.Auth.TokenTTL,
+			AdminAPIKey: cfg.Auth.AdminAPIKey,
+		},
+		userCache:     newUserCache(),
+		crawler:       newCrawler(cfg.Crawler, cfg.Sources),
+		crawlSchedule: crawlSchedule{spec: cfg.Crawler.Schedule},
+	}
+	h.crawlJobs = newCrawlJobRunner(repos.CrawlJobs, repos.Locks, h.ProcessSoftwarePapers)
+	return h
+}
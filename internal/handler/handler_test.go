@@ -0,0 +1,475 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/config"
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
+)
+
+// 基于内存存储的 Handler 和路由，只注册被测的接口，不经过认证
+type testServer struct {
+	t      *testing.T
+	repos  repository.Repositories
+	router *gin.Engine
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	repos := repository.NewMemory()
+	h := New(repos, &cfg)
+
+	r := gin.New()
+	r.UseRawPath = true
+	r.Use(RequestID(), ErrorHandler(), Recovery())
+	r.NoRoute(NoRoute)
+	r.GET("/softwares", h.GetSoftware)
+	r.GET("/softwares/:id", h.GetSoftwareDetail)
+	r.POST("/softwares", h.CreateSoftware)
+	r.PUT("/softwares/:id", h.UpdateSoftware)
+	r.PATCH("/softwares/:id", h.PatchSoftware)
+	r.DELETE("/softwares/:id", h.DeleteSoftware)
+	r.GET("/papers", h.GetPapers)
+	r.GET("/benchmarks", h.GetBenchmarks)
+	r.GET("/softwares/:id/benchmark", h.GetBenchmarksBySoftware)
+	r.POST("/softwares/:id/benchmark", h.CreateBenchmark)
+	r.PUT("/benchmarks/:id", h.UpdateBenchmark)
+	r.DELETE("/benchmarks/:id", h.DeleteBenchmark)
+	r.GET("/reviews", h.GetPaperReviews)
+	r.POST("/reviews/:id/approve", h.ApprovePaperReview)
+	r.POST("/reviews/:id/reject", h.RejectPaperReview)
+	return &testServer{t: t, repos: repos, router: r}
+}
+
+// 发送请求，body 不为 nil 时编码为 JSON；响应体解码到 out（可为 nil），返回状态码
+func (s *testServer) do(method, path string, body, out any) int {
+	s.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if out != nil && w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+// 期望返回 want，否则带上响应体终止测试
+func (s *testServer) expect(want int, method, path string, body, out any) {
+	s.t.Helper()
+	var raw json.RawMessage
+	got := s.do(method, path, body, &raw)
+	if got != want {
+		s.t.Fatalf("%s %s: status %d, want %d: %s", method, path, got, want, raw)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", method, path, raw, err)
+		}
+	}
+}
+
+// 期望返回错误响应，检查状态码、错误码和出错字段（field 为空时不检查）
+func (s *testServer) expectError(status int, code apperr.Code, field, method, path string, body any) {
+	s.t.Helper()
+	var resp struct {
+		Code    apperr.Code       `json:"code"`
+		Details map[string]string `json:"details"`
+	}
+	s.expect(status, method, path, body, &resp)
+	if resp.Code != code {
+		s.t.Errorf("%s %s: code %q, want %q", method, path, resp.Code, code)
+	}
+	if field != "" && resp.Details["field"] != field {
+		s.t.Errorf("%s %s: field %q, want %q", method, path, resp.Details["field"], field)
+	}
+}
+
+func (s *testServer) createSoftware(name string) models.Software {
+	s.t.Helper()
+	var sw models.Software
+	s.expect(http.StatusCreated, "POST", "/softwares", models.Software{Name: name}, &sw)
+	return sw
+}
+
+func validBenchmark(name string) map[string]any {
+	return map[string]any{
+		"name":     name,
+		"dataset":  "benchMEM",
+		"hardware": map[string]any{"cpu_model": "EPYC 7763", "cores": 128, "nodes": 2},
+		"metrics":  map[string]any{"ns_per_day": map[string]any{"value": 42.5, "unit": "ns/day"}},
+	}
+}
+
+func TestSoftwareCRUD(t *testing.T) {
+	s := newTestServer(t)
+
+	var created models.Software
+	s.expect(http.StatusCreated, "POST", "/softwares", map[string]any{
+		"name":       "  GROMACS ",
+		"homepage":   "https://www.gromacs.org",
+		"github":     "https://github.com/gromacs/gromacs",
+		"categories": []string{"molecular dynamics"},
+		"aliases":    []string{"gmx", " GMX ", ""},
+	}, &created)
+	if created.ID == 0 || created.Name != "GROMACS" {
+		t.Fatalf("created = %+v", created)
+	}
+	// 别名去掉空白项和不区分大小写的重复项
+	if len(created.Aliases) != 1 || created.Aliases[0] != "gmx" {
+		t.Errorf("aliases = %q, want [gmx]", created.Aliases)
+	}
+	path := "/softwares/" + strconv.Itoa(created.ID)
+
+	var detail struct {
+		Software   models.Software    `json:"software"`
+		Papers     []models.Paper     `json:"papers"`
+		Benchmarks []models.Benchmark `json:"benchmarks"`
+	}
+	s.expect(http.StatusOK, "GET", path, nil, &detail)
+	if detail.Software.Homepage != "https://www.gromacs.org" || detail.Papers != nil || detail.Benchmarks != nil {
+		t.Errorf("detail = %+v", detail)
+	}
+
+	s.expectError(http.StatusConflict, apperr.Conflict, "", "POST", "/softwares", map[string]any{"name": "GROMACS"})
+	// 软件名不区分大小写唯一
+	s.expectError(http.StatusConflict, apperr.Conflict, "", "POST", "/softwares", map[string]any{"name": "Gromacs"})
+
+	var updated models.Software
+	s.expect(http.StatusOK, "PUT", path, map[string]any{"name": "GROMACS", "abstract": "MD engine"}, &updated)
+	if updated.ID != created.ID || updated.Abstract != "MD engine" || updated.Homepage != "" {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	var patched models.Software
+	s.expect(http.StatusOK, "PATCH", path, map[string]any{"tags": []string{"gpu"}}, &patched)
+	if patched.Abstract != "MD engine" || len(patched.Tags) != 1 || patched.Tags[0] != "gpu" {
+		t.Errorf("patched = %+v", patched)
+	}
+
+	s.expect(http.StatusNoContent, "DELETE", path, nil, nil)
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "GET", path, nil)
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "PUT", path, map[string]any{"name": "GROMACS"})
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "DELETE", path, nil)
+}
+
+func TestSoftwareValidation(t *testing.T) {
+	s := newTestServer(t)
+	sw := s.createSoftware("LAMMPS")
+	s.createSoftware("NAMD")
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   any
+		field  string
+	}{
+		{"POST", "/softwares", map[string]any{"name": "  "}, "name"},
+		{"POST", "/softwares", map[string]any{"name": "A", "homepage": "ftp://example.org"}, "homepage"},
+		{"POST", "/softwares", map[string]any{"name": "A", "github": "https://gitlab.com/a/b"}, "github"},
+		{"POST", "/softwares", map[string]any{"name": "A", "aliases": []string{string(make([]byte, 201))}}, "aliases"},
+		{"PATCH", "/softwares/" + strconv.Itoa(sw.ID), map[string]any{"name": ""}, "name"},
+		{"GET", "/softwares/abc", nil, "id"},
+		{"DELETE", "/softwares/0", nil, "id"},
+	} {
+		s.expectError(http.StatusBadRequest, apperr.Validation, tc.field, tc.method, tc.path, tc.body)
+	}
+
+	s.expectError(http.StatusBadRequest, apperr.Validation, "", "POST", "/softwares", "not an object")
+	// 改名为已有的软件名，大小写不同也算
+	s.expectError(http.StatusConflict, apperr.Conflict, "", "PATCH", "/softwares/"+strconv.Itoa(sw.ID), map[string]any{"name": "NAMD"})
+	s.expectError(http.StatusConflict, apperr.Conflict, "", "PATCH", "/softwares/"+strconv.Itoa(sw.ID), map[string]any{"name": "namd"})
+	// 只改自己名称的大小写不冲突
+	s.expect(http.StatusOK, "PATCH", "/softwares/"+strconv.Itoa(sw.ID), map[string]any{"name": "Lammps"}, nil)
+}
+
+func TestSoftwareNameIgnoresCase(t *testing.T) {
+	s := newTestServer(t)
+	sw := s.createSoftware("GROMACS")
+	ctx := context.Background()
+
+	got, err := s.repos.Software.GetByName(ctx, "gromacs")
+	if err != nil || got.ID != sw.ID {
+		t.Fatalf("GetByName(gromacs) = %+v, %v", got, err)
+	}
+
+	// 按名称关联论文时同样不区分大小写，返回的是软件的规范名称
+	if err := s.repos.Papers.Upsert(ctx, models.Paper{ID: "2101.00001", Title: "MD on GPUs", SoftwareNames: []string{"Gromacs"}}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.repos.Papers.GetByID(ctx, "2101.00001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.SoftwareNames, []string{"GROMACS"}) {
+		t.Errorf("software names = %q, want [GROMACS]", p.SoftwareNames)
+	}
+}
+
+func TestSoftwarePagination(t *testing.T) {
+	s := newTestServer(t)
+	for _, name := range []string{"CP2K", "ABINIT", "GROMACS", "BerkeleyGW", "Quantum ESPRESSO"} {
+		s.createSoftware(name)
+	}
+
+	// 按 name 降序、每页 2 个，沿游标翻到最后一页
+	var names []string
+	path := "/softwares?limit=2&sort=-name"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		var page repository.Page[models.Software]
+		s.expect(http.StatusOK, "GET", path, nil, &page)
+		if page.Total != 5 || page.Limit != 2 {
+			t.Fatalf("page = %+v", page)
+		}
+		for _, sw := range page.Items {
+			names = append(names, sw.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		path = "/softwares?limit=2&sort=-name&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+	want := []string{"Quantum ESPRESSO", "GROMACS", "CP2K", "BerkeleyGW", "ABINIT"}
+	if !slices.Equal(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+
+	var offsetPage, searchPage, limitPage repository.Page[models.Software]
+	s.expect(http.StatusOK, "GET", "/softwares?limit=2&offset=4", nil, &offsetPage)
+	if len(offsetPage.Items) != 1 || offsetPage.Items[0].Name != "Quantum ESPRESSO" || offsetPage.NextCursor != "" {
+		t.Errorf("offset page = %+v", offsetPage)
+	}
+	s.expect(http.StatusOK, "GET", "/softwares?search=espresso", nil, &searchPage)
+	if searchPage.Total != 1 {
+		t.Errorf("search total = %d, want 1", searchPage.Total)
+	}
+	s.expect(http.StatusOK, "GET", "/softwares?limit=1000", nil, &limitPage)
+	if limitPage.Limit != maxPageLimit {
+		t.Errorf("limit = %d, want %d", limitPage.Limit, maxPageLimit)
+	}
+
+	s.expectError(http.StatusBadRequest, apperr.Validation, "", "GET", "/softwares?limit=0", nil)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "", "GET", "/softwares?offset=-1", nil)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "", "GET", "/softwares?sort=homepage", nil)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "", "GET", "/softwares?cursor=bogus", nil)
+	// 游标与排序不一致
+	var first repository.Page[models.Software]
+	s.expect(http.StatusOK, "GET", "/softwares?limit=1&sort=name", nil, &first)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "", "GET", "/softwares?sort=-name&cursor="+url.QueryEscape(first.NextCursor), nil)
+}
+
+func TestPapers(t *testing.T) {
+	s := newTestServer(t)
+	s.createSoftware("GROMACS")
+	s.createSoftware("LAMMPS")
+
+	ctx := context.Background()
+	for _, p := range []models.Paper{
+		{ID: "2101.00001", Title: "GROMACS on GPUs", Authors: []string{"Ada Jones"}, PublishedTime: "2021-01-05", SoftwareNames: []string{"GROMACS"}},
+		{ID: "2202.00002", Title: "LAMMPS at scale", Authors: []string{"Bo Smith"}, PublishedTime: "2022-02-10", SoftwareNames: []string{"LAMMPS"}},
+		{ID: "2303.00003", Title: "Comparing MD engines", Authors: []string{"Ada Jones"}, PublishedTime: "2023-03-15", SoftwareNames: []string{"GROMACS", "LAMMPS"}},
+		// 没有关联软件的论文不公开
+		{ID: "2404.00004", Title: "Unrelated", PublishedTime: "2024-04-01"},
+	} {
+		if err := s.repos.Papers.Upsert(ctx, p); err != nil {
+			t.Fatalf("Upsert %s: %v", p.ID, err)
+		}
+	}
+
+	ids := func(path string) []string {
+		t.Helper()
+		var page repository.Page[models.Paper]
+		s.expect(http.StatusOK, "GET", path, nil, &page)
+		var ids []string
+		for _, p := range page.Items {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		path string
+		want []string
+	}{
+		{"/papers", []string{"2101.00001", "2202.00002", "2303.00003"}},
+		{"/papers?software=gromacs", []string{"2101.00001", "2303.00003"}},
+		{"/papers?author=jones&sort=-id", []string{"2303.00003", "2101.00001"}},
+		{"/papers?from=2022-01-01&to=2022-12-31", []string{"2202.00002"}},
+		{"/papers?search=scale", []string{"2202.00002"}},
+		{"/papers?withdrawn=true", nil},
+		{"/papers?limit=1&offset=1", []string{"2202.00002"}},
+	} {
+		if got := ids(tc.path); !slices.Equal(got, tc.want) {
+			t.Errorf("GET %s = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+
+	s.expectError(http.StatusBadRequest, apperr.Validation, "from", "GET", "/papers?from=2022-13-01", nil)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "to", "GET", "/papers?from=2022-02-01&to=2022-01-01", nil)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "withdrawn", "GET", "/papers?withdrawn=maybe", nil)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "", "GET", "/papers?sort=published", nil)
+}
+
+func TestBenchmarkCRUD(t *testing.T) {
+	s := newTestServer(t)
+	sw := s.createSoftware("GROMACS")
+	other := s.createSoftware("LAMMPS")
+	swPath := "/softwares/" + strconv.Itoa(sw.ID)
+
+	var created models.Benchmark
+	s.expect(http.StatusCreated, "POST", swPath+"/benchmark", validBenchmark(" STMV "), &created)
+	if created.ID == 0 || created.SoftwareID != sw.ID || created.Name != "STMV" {
+		t.Fatalf("created = %+v", created)
+	}
+	s.expect(http.StatusCreated, "POST", swPath+"/benchmark", validBenchmark("benchPEP"), nil)
+	path := "/benchmarks/" + strconv.Itoa(created.ID)
+
+	var list []models.Benchmark
+	s.expect(http.StatusOK, "GET", swPath+"/benchmark", nil, &list)
+	if len(list) != 2 || list[0].ID != created.ID {
+		t.Errorf("benchmarks of software = %+v", list)
+	}
+
+	// 未指定 software_id 时沿用原值
+	var updated models.Benchmark
+	s.expect(http.StatusOK, "PUT", path, validBenchmark("STMV 2"), &updated)
+	if updated.SoftwareID != sw.ID || updated.Name != "STMV 2" {
+		t.Errorf("updated = %+v", updated)
+	}
+	moved := validBenchmark("STMV 2")
+	moved["software_id"] = other.ID
+	s.expect(http.StatusOK, "PUT", path, moved, &updated)
+	if updated.SoftwareID != other.ID {
+		t.Errorf("software_id = %d, want %d", updated.SoftwareID, other.ID)
+	}
+	moved["software_id"] = 999
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "PUT", path, moved)
+
+	// 还有 benchmark 的软件不能删除
+	s.expectError(http.StatusConflict, apperr.Conflict, "", "DELETE", "/softwares/"+strconv.Itoa(other.ID), nil)
+	s.expect(http.StatusNoContent, "DELETE", path, nil, nil)
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "DELETE", path, nil)
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "PUT", path, validBenchmark("STMV"))
+	s.expect(http.StatusNoContent, "DELETE", "/softwares/"+strconv.Itoa(other.ID), nil, nil)
+}
+
+func TestBenchmarkValidation(t *testing.T) {
+	s := newTestServer(t)
+	sw := s.createSoftware("GROMACS")
+	path := "/softwares/" + strconv.Itoa(sw.ID) + "/benchmark"
+
+	with := func(key string, value any) map[string]any {
+		b := validBenchmark("STMV")
+		b[key] = value
+		return b
+	}
+	for _, tc := range []struct {
+		body  any
+		field string
+	}{
+		{with("name", " "), "name"},
+		{with("hardware", map[string]any{}), "hardware"},
+		{with("hardware", map[string]any{"cpu_model": "x", "cores": 1, "nodes": 1, "rack": "A"}), "hardware.rack"},
+		{with("hardware", map[string]any{"cpu_model": "x", "cores": 1}), "hardware.nodes"},
+		{with("hardware", map[string]any{"cpu_model": "x", "cores": 0, "nodes": 1}), "hardware.cores"},
+		{with("metrics", map[string]any{}), "metrics"},
+		{with("metrics", map[string]any{"time": map[string]any{"value": "fast", "unit": "s"}}), "metrics.time.value"},
+		{with("metrics", map[string]any{"time": map[string]any{"value": 1.5}}), "metrics.time.unit"},
+	} {
+		s.expectError(http.StatusBadRequest, apperr.Validation, tc.field, "POST", path, tc.body)
+	}
+
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "POST", "/softwares/999/benchmark", validBenchmark("STMV"))
+	s.expectError(http.StatusBadRequest, apperr.Validation, "id", "PUT", "/benchmarks/x", validBenchmark("STMV"))
+}
+
+func TestBenchmarkPagination(t *testing.T) {
+	s := newTestServer(t)
+	sw := s.createSoftware("GROMACS")
+	for _, name := range []string{"a", "b", "c"} {
+		s.expect(http.StatusCreated, "POST", "/softwares/"+strconv.Itoa(sw.ID)+"/benchmark", validBenchmark(name), nil)
+	}
+
+	// 默认按 id 降序
+	var first, second, byName repository.Page[models.Benchmark]
+	s.expect(http.StatusOK, "GET", "/benchmarks?limit=2", nil, &first)
+	if first.Total != 3 || len(first.Items) != 2 || first.Items[0].Name != "c" || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	s.expect(http.StatusOK, "GET", "/benchmarks?limit=2&cursor="+url.QueryEscape(first.NextCursor), nil, &second)
+	if len(second.Items) != 1 || second.Items[0].Name != "a" || second.NextCursor != "" {
+		t.Errorf("second page = %+v", second)
+	}
+	s.expect(http.StatusOK, "GET", "/benchmarks?sort=name", nil, &byName)
+	if len(byName.Items) != 3 || byName.Items[0].Name != "a" {
+		t.Errorf("sorted by name = %+v", byName)
+	}
+}
+
+func TestPaperReviews(t *testing.T) {
+	s := newTestServer(t)
+	sw := s.createSoftware("GROMACS")
+
+	ctx := context.Background()
+	paper := models.Paper{ID: "2101.00001", Title: "Maybe GROMACS", PublishedTime: "2021-01-05"}
+	review := models.PaperReview{PaperID: paper.ID, SoftwareID: sw.ID, Confidence: 0.4, Reasons: []string{"alias in abstract"}}
+	failed, err := s.repos.Papers.UpsertBatch(ctx, []models.Paper{paper}, []models.PaperReview{review})
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("UpsertBatch: failed=%v err=%v", failed, err)
+	}
+
+	var reviews repository.Page[models.PaperReview]
+	s.expect(http.StatusOK, "GET", "/reviews?software=gromacs", nil, &reviews)
+	if reviews.Total != 1 || reviews.Items[0].PaperTitle != paper.Title || reviews.Items[0].Status != models.ReviewPending {
+		t.Fatalf("reviews = %+v", reviews)
+	}
+	id := strconv.Itoa(int(reviews.Items[0].ID))
+
+	var resolved models.PaperReview
+	s.expect(http.StatusOK, "POST", "/reviews/"+id+"/approve", nil, &resolved)
+	if resolved.Status != models.ReviewApproved || resolved.ReviewedAt == nil {
+		t.Errorf("resolved = %+v", resolved)
+	}
+	// 通过后论文关联到软件并公开
+	var papers repository.Page[models.Paper]
+	s.expect(http.StatusOK, "GET", "/papers?software=GROMACS", nil, &papers)
+	if papers.Total != 1 || papers.Items[0].SoftwareConfidence["GROMACS"] == 0 {
+		t.Errorf("papers = %+v", papers)
+	}
+
+	s.expectError(http.StatusConflict, apperr.Conflict, "", "POST", "/reviews/"+id+"/reject", nil)
+	s.expectError(http.StatusNotFound, apperr.NotFound, "", "POST", "/reviews/999/approve", nil)
+	s.expectError(http.StatusBadRequest, apperr.Validation, "status", "GET", "/reviews?status=done", nil)
+	var pending repository.Page[models.PaperReview]
+	s.expect(http.StatusOK, "GET", "/reviews", nil, &pending)
+	if pending.Total != 0 {
+		t.Errorf("pending reviews = %d, want 0", pending.Total)
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+)
+
+// 就绪检查项，Check 返回错误表示实例暂时不能接收流量
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// 单次就绪检查的总超时，应小于编排系统探针的超时
+const readinessTimeout = 3 * time.Second
+
+// GET /healthz 进程存活即返回 200，不检查依赖，避免数据库故障时编排系统反复重启实例
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /readyz 依次执行 checks，全部通过时返回 200，否则返回 503，details 中列出每项是否通过；
+// 接口公开访问，具体错误只写日志
+func Readyz(checks ...ReadinessCheck) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		results := make(map[string]string, len(checks))
+		var errs []error
+		for _, chk := range checks {
+			if err := chk.Check(ctx); err != nil {
+				results[chk.Name] = "failed"
+				// 不保留错误链，检查超时也按未就绪返回 503 而不是 504
+				errs = append(errs, fmt.Errorf("%s: %v", chk.Name, err))
+				continue
+			}
+			results[chk.Name] = "ok"
+		}
+		if len(errs) > 0 {
+			c.Error(apperr.Wrap(apperr.Unavailable, "service not ready", errors.Join(errs...)).WithDetails(results))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": results})
+	}
+}
+
+// 连接池统计
+type dbStatsResponse struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// GET /admin/db/stats 数据库连接池统计，wait_count 持续增长说明连接池不够用
+func DBStats(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := db.Stats()
+		c.JSON(http.StatusOK, dbStatsResponse{
+			MaxOpenConnections: s.MaxOpenConnections,
+			OpenConnections:    s.OpenConnections,
+			InUse:              s.InUse,
+			Idle:               s.Idle,
+			WaitCount:          s.WaitCount,
+			WaitDuration:       s.WaitDuration.String(),
+			MaxIdleClosed:      s.MaxIdleClosed,
+			MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  s.MaxLifetimeClosed,
+		})
+	}
+}
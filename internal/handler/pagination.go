@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/repository"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// 解析列表接口通用的分页参数：limit、offset、cursor、sort（"-" 前缀表示降序）
+func parsePageRequest(c *gin.Context) (repository.PageRequest, error) {
+	page := repository.PageRequest{Limit: defaultPageLimit}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return page, apperr.New(apperr.Validation, "limit must be a positive integer")
+		}
+		page.Limit = min(n, maxPageLimit)
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return page, apperr.New(apperr.Validation, "offset must be a non-negative integer")
+		}
+		page.Offset = n
+	}
+	page.Cursor = c.Query("cursor")
+
+	if v := c.Query("sort"); v != "" {
+		page.Desc = strings.HasPrefix(v, "-")
+		page.Sort = strings.TrimLeft(v, "+-")
+	}
+	return page, nil
+}
@@ -1,18 +1,66 @@
 package handler
 
 import (
-	"context"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"hpc-site/internal/repository"
 )
 
+// 解析论文过滤参数：software、author、from、to（YYYY-MM-DD）、search、withdrawn
+func parsePaperFilter(c *gin.Context) (repository.PaperFilter, error) {
+	filter := repository.PaperFilter{
+		Software: c.Query("software"),
+		Author:   c.Query("author"),
+		Search:   c.Query("search"),
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, fieldError(p.name, "%s must be a date in YYYY-MM-DD format", p.name)
+		}
+		*p.dst = &t
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return filter, fieldError("to", "to must not be earlier than from")
+	}
+
+	if v := c.Query("withdrawn"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fieldError("withdrawn", "withdrawn must be true or false")
+		}
+		filter.Withdrawn = &b
+	}
+	return filter, nil
+}
+
 // GET /papers
-func GetPapers(c *gin.Context) {
-	papers, err := repository.GetAllPapers(context.Background())
+func (h *Handler) GetPapers(c *gin.Context) {
+	filter, err := parsePaperFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	papers, err := h.papers.Query(c.Request.Context(), filter, page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, papers)
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/models"
+)
+
+// GET /reviews?status=pending&software=... 论文—软件关联的审核队列，status 默认 pending，传 all 查看全部
+func (h *Handler) GetPaperReviews(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ReviewPending)
+	switch status {
+	case models.ReviewPending, models.ReviewApproved, models.ReviewRejected:
+	case "all":
+		status = ""
+	default:
+		c.Error(fieldError("status", "status must be one of pending, approved, rejected, all"))
+		return
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	reviews, err := h.reviews.Query(c.Request.Context(), status, c.Query("software"), page)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+// POST /reviews/:id/approve 确认关联，把软件添加到论文上
+func (h *Handler) ApprovePaperReview(c *gin.Context) {
+	h.resolvePaperReview(c, true)
+}
+
+// POST /reviews/:id/reject 拒绝关联，之后的抓取不会再把该论文放入队列
+func (h *Handler) RejectPaperReview(c *gin.Context) {
+	h.resolvePaperReview(c, false)
+}
+
+func (h *Handler) resolvePaperReview(c *gin.Context, approve bool) {
+	id, ok := parseIDParam(c, "invalid review id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.reviews.Resolve(ctx, id, approve); err != nil {
+		c.Error(err)
+		return
+	}
+
+	review, err := h.reviews.GetByID(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
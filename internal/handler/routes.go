@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/models"
+)
+
+// 注册业务接口，r 上应已使用 Authenticate。
+// 查询接口公开，其余按角色授权：viewer 查看审核队列和抓取任务，curator 修改数据，admin 抓取全部软件和管理用户
+func (h *Handler) RegisterRoutes(r gin.IRouter) {
+	viewer := r.Group("", RequireRole(models.RoleViewer))
+	curator := r.Group("", RequireRole(models.RoleCurator))
+	admin := r.Group("", RequireRole(models.RoleAdmin))
+
+	// 认证
+	viewer.POST("/auth/token", h.IssueToken)
+	viewer.GET("/auth/me", h.GetCurrentUser)
+	admin.GET("/admin/users", h.GetUsers)
+	admin.POST("/admin/users", h.CreateUser)
+	admin.PATCH("/admin/users/:id", h.PatchUser)
+	admin.GET("/admin/users/:id/api-keys", h.GetAPIKeys)
+	admin.POST("/admin/users/:id/api-keys", h.CreateAPIKey)
+	admin.DELETE("/admin/api-keys/:id", h.RevokeAPIKey)
+
+	// 软件和论文
+	r.GET("/softwares", h.GetSoftware)
+	r.GET("/softwares/:id", h.GetSoftwareDetail)
+	curator.POST("/softwares", h.CreateSoftware)
+	curator.PUT("/softwares/:id", h.UpdateSoftware)
+	curator.PATCH("/softwares/:id", h.PatchSoftware)
+	curator.DELETE("/softwares/:id", h.DeleteSoftware)
+	r.GET("/papers", h.GetPapers)
+	curator.POST("/papers/:id/refresh", h.RefreshPaper)
+	curator.POST("/softwares/:id/crawl", h.CrawlSoftware)
+	r.GET("/search", h.Search)
+	// benchmark
+	r.GET("/benchmarks", h.GetBenchmarks)
+	r.GET("/softwares/:id/benchmark", h.GetBenchmarksBySoftware)
+	curator.POST("/softwares/:id/benchmark", h.CreateBenchmark)
+	curator.PUT("/benchmarks/:id", h.UpdateBenchmark)
+	curator.DELETE("/benchmarks/:id", h.DeleteBenchmark)
+	// 审核队列
+	viewer.GET("/reviews", h.GetPaperReviews)
+	curator.POST("/reviews/:id/approve", h.ApprovePaperReview)
+	curator.POST("/reviews/:id/reject", h.RejectPaperReview)
+	// 抓取任务
+	admin.POST("/crawl/all", h.GetAllSoftwarePaper)
+	viewer.GET("/crawl/jobs/:id", h.GetCrawlJob)
+	curator.DELETE("/crawl/jobs/:id", h.CancelCrawlJob)
+	viewer.GET("/crawl/schedule", h.GetCrawlSchedule)
+	viewer.GET("/crawl/runs", h.GetCrawlRuns)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
+)
+
+// GET /search?q=&type=software,paper,benchmark
+func (h *Handler) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.Error(fieldError("q", "q is required"))
+		return
+	}
+
+	var types []string
+	if v := c.Query("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(repository.SearchTypes, t) {
+				c.Error(fieldError("type", "type must be one of software, paper, benchmark"))
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if page.Cursor != "" || page.Sort != "" {
+		c.Error(apperr.New(apperr.Validation, "search results are ranked by relevance and only support limit/offset"))
+		return
+	}
+
+	results, total, err := h.search.Search(c.Request.Context(), q, types, page.Limit, page.Offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if results == nil {
+		results = []models.SearchResult{}
+	}
+
+	c.JSON(http.StatusOK, repository.Page[models.SearchResult]{
+		Items:  results,
+		Total:  total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+}
@@ -1,58 +1,63 @@
 package handler
 
 import (
-	"context"
 	"github.com/gin-gonic/gin"
+	"hpc-site/internal/models"
 	"hpc-site/internal/repository"
 	"net/http"
-	"strconv"
+	"net/url"
+	"strings"
 )
 
-func GetSoftware(c *gin.Context) {
-	ctx := context.Background()
+func (h *Handler) GetSoftware(c *gin.Context) {
+	ctx := c.Request.Context()
 
 	// 获取 query 参数
-	name := c.Query("name")
-	category := c.Query("category")
-	tag := c.Query("tag")
-	search := c.Query("search")
+	filter := repository.SoftwareFilter{
+		Name:     c.Query("name"),
+		Category: c.Query("category"),
+		Tag:      c.Query("tag"),
+		Search:   c.Query("search"),
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	softwares, err := repository.QuerySoftware(ctx, name, category, tag, search)
+	softwares, err := h.software.Query(ctx, filter, page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, softwares)
 }
 
-func GetSoftwareDetail(c *gin.Context) {
-	ctx := context.Background()
-	idStr := c.Param("id")
-
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+func (h *Handler) GetSoftwareDetail(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := parseSoftwareID(c)
+	if !ok {
 		return
 	}
 
 	// 查软件
-	software, err := repository.GetSoftwareByID(ctx, id)
+	software, err := h.software.GetByID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "software not found"})
+		c.Error(err)
 		return
 	}
 
 	// 查相关论文
-	papers, err := repository.GetPapersBySoftwareID(ctx, id)
+	papers, err := h.papers.GetBySoftwareID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	//查相关benchmark
-	benchmarks, err := repository.GetBenchmarksBySoftwareID(ctx, id)
+	benchmarks, err := h.benchmarks.ListBySoftwareID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -62,3 +67,201 @@ func GetSoftwareDetail(c *gin.Context) {
 		"benchmarks": benchmarks,
 	})
 }
+
+// PATCH 请求体，未提供的字段保持原值
+type softwarePatch struct {
+	Name         *string   `json:"name"`
+	Abstract     *string   `json:"abstract"`
+	Homepage     *string   `json:"homepage"`
+	Github       *string   `json:"github"`
+	Categories   *[]string `json:"categories"`
+	Tags         *[]string `json:"tags"`
+	Aliases      *[]string `json:"aliases"`
+	ExcludeTerms *[]string `json:"exclude_terms"`
+}
+
+const (
+	maxSearchTerms   = 20  // 别名、排除词各自的最大个数
+	maxSearchTermLen = 200 // 单个别名、排除词的最大长度
+)
+
+// 校验软件字段：名称非空，homepage/github 为合法的 http(s) 地址。返回 Validation 错误，details 中为出错的字段
+func validateSoftware(s *models.Software) error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Homepage = strings.TrimSpace(s.Homepage)
+	s.Github = strings.TrimSpace(s.Github)
+	if s.Name == "" {
+		return fieldError("name", "name is required")
+	}
+	if len(s.Name) > 200 {
+		return fieldError("name", "name must be at most 200 characters")
+	}
+	var err error
+	if s.Aliases, err = normalizeTerms("aliases", s.Aliases); err != nil {
+		return err
+	}
+	if s.ExcludeTerms, err = normalizeTerms("exclude_terms", s.ExcludeTerms); err != nil {
+		return err
+	}
+	if s.Homepage != "" && !isHTTPURL(s.Homepage) {
+		return fieldError("homepage", "homepage must be a valid http(s) URL")
+	}
+	if s.Github != "" {
+		u, err := url.Parse(s.Github)
+		if err != nil || !isHTTPURL(s.Github) || !strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "github.com") {
+			return fieldError("github", "github must be a valid https://github.com/... URL")
+		}
+	}
+	return nil
+}
+
+// 去掉空白项和（不区分大小写的）重复项，并限制个数和长度
+func normalizeTerms(field string, terms []string) ([]string, error) {
+	out := make([]string, 0, len(terms))
+	seen := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		t = strings.Join(strings.Fields(t), " ")
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		if len(t) > maxSearchTermLen {
+			return nil, fieldError(field, "each of %s must be at most %d characters", field, maxSearchTermLen)
+		}
+		seen[strings.ToLower(t)] = true
+		out = append(out, t)
+	}
+	if len(out) > maxSearchTerms {
+		return nil, fieldError(field, "%s must have at most %d items", field, maxSearchTerms)
+	}
+	return out, nil
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.ParseRequestURI(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func parseSoftwareID(c *gin.Context) (int, bool) {
+	id, ok := parseIDParam(c, "invalid software id")
+	return int(id), ok
+}
+
+// POST /softwares
+func (h *Handler) CreateSoftware(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var s models.Software
+	if err := c.ShouldBindJSON(&s); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	if err := validateSoftware(&s); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.software.Create(ctx, &s); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+// PUT /softwares/:id
+func (h *Handler) UpdateSoftware(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := parseSoftwareID(c)
+	if !ok {
+		return
+	}
+
+	var s models.Software
+	if err := c.ShouldBindJSON(&s); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	s.ID = id
+	if err := validateSoftware(&s); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.software.Update(ctx, &s); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// PATCH /softwares/:id
+func (h *Handler) PatchSoftware(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := parseSoftwareID(c)
+	if !ok {
+		return
+	}
+
+	var patch softwarePatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	s, err := h.software.GetByID(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if patch.Name != nil {
+		s.Name = *patch.Name
+	}
+	if patch.Abstract != nil {
+		s.Abstract = *patch.Abstract
+	}
+	if patch.Homepage != nil {
+		s.Homepage = *patch.Homepage
+	}
+	if patch.Github != nil {
+		s.Github = *patch.Github
+	}
+	if patch.Categories != nil {
+		s.Categories = *patch.Categories
+	}
+	if patch.Tags != nil {
+		s.Tags = *patch.Tags
+	}
+	if patch.Aliases != nil {
+		s.Aliases = *patch.Aliases
+	}
+	if patch.ExcludeTerms != nil {
+		s.ExcludeTerms = *patch.ExcludeTerms
+	}
+	if err := validateSoftware(s); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.software.Update(ctx, s); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// DELETE /softwares/:id 软件还有 benchmark 时返回 409，需先删除其 benchmark；论文关联和抓取状态随软件一起删除
+func (h *Handler) DeleteSoftware(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := parseSoftwareID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.software.Delete(ctx, id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
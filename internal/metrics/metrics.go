@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 所有指标注册到 prometheus 默认的 registry，由 /metrics 导出
+const namespace = "hpc"
+
+// HTTP 接口。route 使用路由模板（如 /softwares/:id），未匹配的路由记为 unmatched，避免标签基数失控
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// 抓取
+var (
+	// 成功（200）返回给调用方的页面
+	CrawlerPagesFetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "pages_fetched_total",
+		Help:      "Pages fetched successfully by host.",
+	}, []string{"host"})
+
+	// 每次请求（包括被重试的）的状态码，网络错误记为 error
+	CrawlerResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "http_responses_total",
+		Help:      "Crawler HTTP responses by host and status code.",
+	}, []string{"host", "code"})
+
+	CrawlerRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "retries_total",
+		Help:      "Crawler request retries by host and reason (status code or error).",
+	}, []string{"host", "reason"})
+
+	// outcome: inserted | updated | skipped | queued | failed
+	CrawlerPapers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "papers_total",
+		Help:      "Papers processed by software and outcome.",
+	}, []string{"software", "outcome"})
+
+	// arXiv 页面抓到了但提取不到内容，通常说明页面结构变了
+	CrawlerParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "parse_failures_total",
+		Help:      "arXiv page fields that could not be extracted, by extractor.",
+	}, []string{"extractor"})
+
+	// state: succeeded | failed | cancelled | interrupted
+	CrawlerSoftwareDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "software_duration_seconds",
+		Help:      "Time spent crawling the papers of one software, by final state.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14), // 1s ~ 2.3h
+	}, []string{"state"})
+
+	CrawlerJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "job_duration_seconds",
+		Help:      "Time spent running a crawl job, by final state.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 16), // 1s ~ 9h
+	}, []string{"state"})
+)
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// 抓取任务状态
+const (
+	CrawlJobQueued    = "queued"
+	CrawlJobRunning   = "running"
+	CrawlJobSucceeded = "succeeded"
+	CrawlJobFailed    = "failed"
+	CrawlJobCancelled = "cancelled"
+	// 服务退出时被中断，已保存断点，下次启动时继续执行
+	CrawlJobInterrupted = "interrupted"
+)
+
+// 论文抓取计数
+type CrawlStats struct {
+	Found    int `json:"found"`
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+	Queued   int `json:"queued"` // 置信度不足、进入人工审核的论文
+	Failed   int `json:"failed"`
+}
+
+func (s *CrawlStats) Add(o CrawlStats) {
+	s.Found += o.Found
+	s.Inserted += o.Inserted
+	s.Updated += o.Updated
+	s.Skipped += o.Skipped
+	s.Queued += o.Queued
+	s.Failed += o.Failed
+}
+
+// 单个软件在任务中的进度
+type CrawlSoftwareProgress struct {
+	Software string `json:"software"`
+	State    string `json:"state"`
+	CrawlStats
+	Errors     []string         `json:"errors,omitempty"`
+	Checkpoint *CrawlCheckpoint `json:"checkpoint,omitempty"` // 中断时的断点，恢复后从这里继续
+}
+
+// 单个软件的抓取断点。候选论文按页（每页固定篇数）处理，一页全部处理完才前进，
+// 恢复时重新检索候选论文，从 LastID 之后继续；找不到 LastID 时从头处理，已入库的论文会被跳过
+type CrawlCheckpoint struct {
+	Page      int        `json:"page"`       // 已处理完的页数
+	LastID    string     `json:"last_id"`    // 已处理完的最后一篇候选论文
+	StartedAt time.Time  `json:"started_at"` // 首次开始抓取该软件的时间，恢复后仍按它记录增量抓取状态
+	Stats     CrawlStats `json:"stats"`      // 处理到断点为止的计数，恢复后从这里接着累加
+}
+
+// 异步抓取任务，Softwares 以 JSONB 存储
+type CrawlJob struct {
+	ID        int64                   `json:"id"`
+	State     string                  `json:"state"`
+	Softwares []CrawlSoftwareProgress `json:"softwares"`
+	Full      bool                    `json:"full"` // 强制完整抓取，忽略上次抓取的状态
+	// 已请求取消，运行中的任务由执行它的实例在处理完当前页后结束
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+	// 持有任务的实例及其租约，租约由该实例定期续约，结束或中断的任务没有租约
+	InstanceID     string     `json:"instance_id,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+	CrawlStats
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// 任务是否已结束，interrupted 的任务会在下次启动时继续，不算结束
+func (j *CrawlJob) Finished() bool {
+	return j.State == CrawlJobSucceeded || j.State == CrawlJobFailed || j.State == CrawlJobCancelled
+}
+
+// 定时抓取的一次执行，State 取值同抓取任务；JobID 为该次触发的抓取任务
+type CrawlRun struct {
+	ID         int64      `json:"id"`
+	State      string     `json:"state"`
+	JobID      *int64     `json:"job_id,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// 单个软件的抓取状态，用于增量抓取
+type SoftwareCrawlState struct {
+	SoftwareID    int        `json:"software_id"`
+	LastCrawledAt *time.Time `json:"last_crawled_at,omitempty"` // 最近一次抓取（无论成败）开始的时间
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"` // 最近一次完整成功抓取开始的时间
+	NewestArxivID string     `json:"newest_arxiv_id,omitempty"` // 抓取结果中见过的最新 arXiv ID
+}
@@ -3,13 +3,22 @@ package models
 import "time"
 
 type Paper struct {
-	ID            string    `db:"id" json:"id"`
-	Title         string    `db:"title" json:"title"`
-	Authors       []string  `db:"authors" json:"authors"`
-	Abstract      string    `db:"abstract" json:"abstract"`
-	URL           string    `db:"url" json:"url"`
-	SoftwareNames []string  `db:"software_names" json:"software_names"`
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
-	Pdf           string    `db:"pdf" json:"pdf"`
-	PublishedTime string    `db:"published_time" json:"published_time"`
+	ID                 string             `db:"id" json:"id"`
+	Title              string             `db:"title" json:"title"`
+	Authors            []string           `db:"authors" json:"authors"`
+	Abstract           string             `db:"abstract" json:"abstract"`
+	URL                string             `db:"url" json:"url"`
+	SoftwareNames      []string           `db:"software_names" json:"software_names"` // 关联的软件名，存储在 paper_software 关联表
+	CreatedAt          time.Time          `db:"created_at" json:"created_at"`
+	Pdf                string             `db:"pdf" json:"pdf"`
+	PublishedTime      string             `db:"published_time" json:"published_time"`
+	Withdrawn          bool               `db:"withdrawn" json:"withdrawn"`
+	UpdatedTime        string             `db:"updated_time" json:"updated_time"`     // 最新版本的提交时间
+	Version            int                `db:"latest_version" json:"latest_version"` // 最新版本号，即共有多少个版本
+	Categories         []string           `db:"categories" json:"categories"`
+	DOI                string             `db:"doi" json:"doi"`
+	JournalRef         string             `db:"journal_ref" json:"journal_ref"`
+	Source             string             `db:"source" json:"source"`                                     // arxiv / crossref / openalex
+	ExternalIDs        map[string]string  `db:"external_ids" json:"external_ids"`                         // 各来源下的标识，如 {"arxiv": "...", "doi": "...", "openalex": "W..."}
+	SoftwareConfidence map[string]float64 `db:"software_confidence" json:"software_confidence,omitempty"` // 软件名 → 关联的置信度
 }
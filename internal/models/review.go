@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// 审核状态
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// 待审核的论文—软件关联：抓取时置信度低于阈值的论文不直接关联到软件，由人工确认
+type PaperReview struct {
+	ID           int64      `json:"id"`
+	PaperID      string     `json:"paper_id"`
+	PaperTitle   string     `json:"paper_title"`
+	SoftwareID   int        `json:"software_id"`
+	SoftwareName string     `json:"software_name"`
+	Confidence   float64    `json:"confidence"`
+	Reasons      []string   `json:"reasons"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// 全文检索的单条结果，Type 为 software / paper / benchmark。
+// Snippet 是已转义的 HTML，命中的词用 <mark> 标出；Title 是纯文本
+type SearchResult struct {
+	Type    string  `json:"type"`
+	ID      string  `json:"id"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
@@ -5,12 +5,14 @@ import (
 )
 
 type Software struct {
-	ID         int       `db:"id" json:"id"`
-	Name       string    `db:"name" json:"name"`
-	Abstract   string    `db:"abstract" json:"abstract"`
-	Homepage   string    `db:"homepage" json:"homepage"`
-	Github     string    `db:"github" json:"github"`
-	Categories []string  `db:"categories" json:"categories"`
-	Tags       []string  `db:"tags" json:"tags"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	ID           int       `db:"id" json:"id"`
+	Name         string    `db:"name" json:"name"`
+	Abstract     string    `db:"abstract" json:"abstract"`
+	Homepage     string    `db:"homepage" json:"homepage"`
+	Github       string    `db:"github" json:"github"`
+	Categories   []string  `db:"categories" json:"categories"`
+	Tags         []string  `db:"tags" json:"tags"`
+	Aliases      []string  `db:"aliases" json:"aliases"`             // 检索论文时额外使用的名称，如 "QE"、"pw.x"
+	ExcludeTerms []string  `db:"exclude_terms" json:"exclude_terms"` // 出现这些词的论文大概率与软件无关
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 }
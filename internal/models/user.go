@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// 角色，权限依次递增：viewer 可查看审核队列和抓取任务，curator 可修改软件、Benchmark、审核论文和抓取单个软件，
+// admin 可抓取全部软件并管理用户和 API key
+const (
+	RoleViewer  = "viewer"
+	RoleCurator = "curator"
+	RoleAdmin   = "admin"
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleCurator: 2, RoleAdmin: 3}
+
+// 是否为已知角色
+func ValidRole(role string) bool {
+	return roleRank[role] > 0
+}
+
+// role 是否具有 required 角色的权限
+func RoleAtLeast(role, required string) bool {
+	return ValidRole(role) && roleRank[role] >= roleRank[required]
+}
+
+type User struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// API key 的元数据，明文只在创建时返回一次
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
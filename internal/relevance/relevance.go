@@ -0,0 +1,147 @@
+package relevance
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"hpc-site/internal/models"
+)
+
+// 各项证据的权重：标题、摘要各自命中软件名或别名即可达到默认阈值
+const (
+	titleWeight    = 0.5
+	abstractWeight = 0.5
+	caseMismatch   = 0.5 // 全大写的缩写（NAMD、CP2K）只在忽略大小写时才命中，权重减半
+	excludePenalty = 0.3 // 出现排除词时置信度乘以该系数
+)
+
+// 默认的发布阈值，低于该值的关联进入人工审核
+const DefaultThreshold = 0.5
+
+// 论文与软件的相关度评分结果
+type Match struct {
+	Confidence float64
+	Reasons    []string
+}
+
+// 某个软件的评分器：软件名、别名和排除词的正则在创建时编译一次，抓取时对每篇论文复用。
+// 只读，可在多个 goroutine 中并发使用
+type Scorer struct {
+	names    []term // 软件名及别名
+	excludes []term
+}
+
+// 编译后的名称或排除词
+type term struct {
+	text      string
+	exact     *regexp.Regexp // 区分大小写，只有全大写的缩写才需要
+	caseFold  *regexp.Regexp // 忽略大小写
+	isAcronym bool
+}
+
+func NewScorer(sw models.Software) *Scorer {
+	s := &Scorer{}
+	for _, t := range append([]string{sw.Name}, sw.Aliases...) {
+		if c, ok := compileTerm(t); ok {
+			s.names = append(s.names, c)
+		}
+	}
+	for _, t := range sw.ExcludeTerms {
+		if c, ok := compileTerm(t); ok {
+			s.excludes = append(s.excludes, c)
+		}
+	}
+	return s
+}
+
+// 根据标题和摘要中是否出现软件名、别名和排除词给论文打分，结果在 [0, 1] 之间
+func (s *Scorer) Score(title, abstract string) Match {
+	var m Match
+	best := func(text string) (float64, string) {
+		var score float64
+		var matched string
+		for _, t := range s.names {
+			if v := t.score(text); v > score {
+				score, matched = v, t.text
+			}
+		}
+		return score, matched
+	}
+
+	if v, term := best(title); v > 0 {
+		m.Confidence += titleWeight * v
+		m.Reasons = append(m.Reasons, fmt.Sprintf("title mentions %q", term))
+	}
+	if v, term := best(abstract); v > 0 {
+		m.Confidence += abstractWeight * v
+		m.Reasons = append(m.Reasons, fmt.Sprintf("abstract mentions %q", term))
+	}
+	if m.Confidence == 0 {
+		m.Reasons = append(m.Reasons, "name not found in title or abstract")
+	}
+
+	for _, t := range s.excludes {
+		if t.score(title) > 0 || t.score(abstract) > 0 {
+			m.Confidence *= excludePenalty
+			m.Reasons = append(m.Reasons, fmt.Sprintf("mentions excluded term %q", t.text))
+			break
+		}
+	}
+	m.Confidence = min(m.Confidence, 1)
+	return m
+}
+
+// 按整词（多词名称按短语，允许空白或连字符分隔）编译匹配正则，空白的词返回 false
+func compileTerm(text string) (term, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return term{}, false
+	}
+	words := strings.Fields(text)
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	pattern := `(?:^|[^\pL\pN])` + strings.Join(words, `[\s\-]+`) + `(?:$|[^\pL\pN])`
+
+	t := term{text: text, caseFold: regexp.MustCompile(`(?i)` + pattern), isAcronym: isAcronym(text)}
+	if t.isAcronym {
+		t.exact = regexp.MustCompile(pattern)
+	}
+	return t, true
+}
+
+// 命中返回 1；全大写的缩写只在忽略大小写时命中返回 caseMismatch，未命中返回 0
+func (t term) score(text string) float64 {
+	if text == "" {
+		return 0
+	}
+	if t.isAcronym {
+		if t.exact.MatchString(text) {
+			return 1
+		}
+		if t.caseFold.MatchString(text) {
+			return caseMismatch
+		}
+		return 0
+	}
+	if t.caseFold.MatchString(text) {
+		return 1
+	}
+	return 0
+}
+
+// 不含小写字母且至少有一个大写字母，如 NAMD、CP2K
+func isAcronym(term string) bool {
+	hasUpper := false
+	for _, r := range term {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsUpper(r) {
+			hasUpper = true
+		}
+	}
+	return hasUpper
+}
@@ -0,0 +1,111 @@
+package relevance
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"hpc-site/internal/models"
+)
+
+func TestScore(t *testing.T) {
+	vasp := models.Software{Name: "VASP"}
+	lammps := models.Software{Name: "LAMMPS"}
+	qe := models.Software{Name: "Quantum ESPRESSO", Aliases: []string{"QE", "pw.x", " "}}
+	amber := models.Software{Name: "Amber", ExcludeTerms: []string{"amber light", "fossil amber"}}
+
+	for _, tc := range []struct {
+		name            string
+		sw              models.Software
+		title, abstract string
+		want            float64
+	}{
+		// 标题或摘要命中一处即达到默认阈值，两处都命中为 1
+		{"acronym in title", vasp, "Phonons of MoS2 computed with VASP", "", 0.5},
+		{"acronym in abstract", vasp, "Phonons of MoS2", "We use VASP with PAW potentials.", 0.5},
+		{"acronym in both", vasp, "VASP study of MoS2", "Computed with VASP.", 1},
+		{"acronym with punctuation", lammps, "LAMMPS-based simulation of (LAMMPS) melts", "", 0.5},
+		{"not found", vasp, "Phonons of MoS2", "Computed with Quantum ESPRESSO.", 0},
+
+		// 全大写的缩写作为其他词的一部分不算命中
+		{"acronym prefix of word", vasp, "VASPKIT: a toolkit for post-processing", "", 0},
+		{"acronym suffix of word", lammps, "Scripting molecular dynamics with pyLAMMPS", "", 0},
+		{"acronym inside word", vasp, "The EVASPORATION rate", "", 0},
+		{"acronym followed by digit", lammps, "LAMMPS2 benchmarks", "", 0},
+
+		// 大小写不同的缩写权重减半，低于阈值
+		{"lowercase acronym", vasp, "Running vasp on GPUs", "", 0.25},
+		{"capitalised acronym", lammps, "Lammps on GPUs", "lammps input files", 0.5},
+
+		// 别名与软件名同等对待，多词名称允许空白或连字符分隔，空白的别名被忽略
+		{"name with hyphen", qe, "Quantum-ESPRESSO benchmarks", "", 0.5},
+		{"name case insensitive", qe, "quantum  espresso on ARM", "", 0.5},
+		{"acronym alias", qe, "DFT study of graphene", "Calculations were done with QE.", 0.5},
+		{"acronym alias substring", qe, "QED corrections to the Lamb shift", "", 0},
+		{"lowercase acronym alias", qe, "Graphene with qe", "", 0.25},
+		{"alias with dot", qe, "", "Self-consistent runs with pw.x", 0.5},
+		{"alias dot is literal", qe, "", "Runs with pwax", 0},
+
+		// 出现排除词时置信度乘以 0.3
+		{"no exclude term", amber, "Amber force field for RNA", "", 0.5},
+		{"exclude term in abstract", amber, "Amber force field for RNA", "Insects trapped in fossil amber.", 0.3},
+		{"exclude term in title", amber, "Amber light emission", "", 0.15},
+		{"exclude term only", amber, "", "fossil amber", 0.15},
+	} {
+		m := NewScorer(tc.sw).Score(tc.title, tc.abstract)
+		if math.Abs(m.Confidence-tc.want) > 1e-9 {
+			t.Errorf("%s: confidence %v, want %v (%v)", tc.name, m.Confidence, tc.want, m.Reasons)
+		}
+		if len(m.Reasons) == 0 {
+			t.Errorf("%s: no reasons", tc.name)
+		}
+	}
+}
+
+func TestScoreThreshold(t *testing.T) {
+	s := NewScorer(models.Software{Name: "GROMACS", Aliases: []string{"gmx"}, ExcludeTerms: []string{"tutorial"}})
+	for _, tc := range []struct {
+		title, abstract string
+		published       bool
+	}{
+		{"GROMACS simulations of lipid bilayers", "", true},
+		{"Lipid bilayers", "Simulated with gmx mdrun", true},
+		{"Gromacs simulations of lipid bilayers", "", false},
+		{"GROMACS tutorial", "A tutorial on GROMACS", false},
+		{"Lipid bilayers", "", false},
+	} {
+		if m := s.Score(tc.title, tc.abstract); (m.Confidence >= DefaultThreshold) != tc.published {
+			t.Errorf("Score(%q, %q) = %v, published want %v", tc.title, tc.abstract, m.Confidence, tc.published)
+		}
+	}
+}
+
+func TestScoreReasons(t *testing.T) {
+	s := NewScorer(models.Software{Name: "NAMD", Aliases: []string{"Nanoscale Molecular Dynamics"}, ExcludeTerms: []string{"namd2 crash"}})
+	m := s.Score("NAMD on Frontier", "Benchmarks of nanoscale molecular dynamics; a namd2 crash is reported.")
+	want := []string{`title mentions "NAMD"`, `abstract mentions "Nanoscale Molecular Dynamics"`, `mentions excluded term "namd2 crash"`}
+	if strings.Join(m.Reasons, "; ") != strings.Join(want, "; ") {
+		t.Errorf("reasons = %q, want %q", m.Reasons, want)
+	}
+
+	if m := s.Score("", ""); m.Confidence != 0 || len(m.Reasons) != 1 {
+		t.Errorf("empty paper: %+v", m)
+	}
+}
+
+func TestIsAcronym(t *testing.T) {
+	for term, want := range map[string]bool{
+		"VASP":    true,
+		"CP2K":    true,
+		"NWCHEM":  true,
+		"LAMMPS":  true,
+		"Amber":   false,
+		"pw.x":    false,
+		"2024":    false,
+		"GROMACS": true,
+	} {
+		if got := isAcronym(term); got != want {
+			t.Errorf("isAcronym(%q) = %v", term, got)
+		}
+	}
+}
@@ -2,15 +2,71 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
 
 	"hpc-site/internal/models"
-	"hpc-site/pkg"
 )
 
-// 获取所有 Benchmark
-func GetAllBenchmarks(ctx context.Context) ([]models.Benchmark, error) {
-	query := `
+// 基于 PostgreSQL 的 BenchmarkRepository
+type PostgresBenchmarkRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresBenchmarkRepository(db *sql.DB, queryTimeout time.Duration) *PostgresBenchmarkRepository {
+	return &PostgresBenchmarkRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// Benchmark 列表可排序的列
+var benchmarkSort = sortSpec{
+	columns: map[string]sortColumn{
+		"id":          {expr: "id", cast: "::int"},
+		"name":        {expr: "COALESCE(name, '')", cast: "::text"},
+		"software_id": {expr: "software_id", cast: "::int"},
+		"created_at":  {expr: "created_at", cast: "::timestamp"},
+	},
+	defaultSort: "id",
+	defaultDesc: true,
+	id:          sortColumn{expr: "id", cast: "::int"},
+}
+
+func benchmarkSortKey(b models.Benchmark, sort string) (string, string) {
+	id := strconv.Itoa(b.ID)
+	switch sort {
+	case "name":
+		return b.Name, id
+	case "software_id":
+		return strconv.Itoa(b.SoftwareID), id
+	case "created_at":
+		return b.CreatedAt.Format(time.RFC3339Nano), id
+	}
+	return id, id
+}
+
+// 获取 Benchmark 列表（分页）
+func (r *PostgresBenchmarkRepository) List(ctx context.Context, page PageRequest) (*Page[models.Benchmark], error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	where := `
+		FROM benchmark
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	total, err := countRows(ctx, r.db, "SELECT COUNT(*)"+where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := page.apply(`
 		SELECT
 			id,
 			software_id,
@@ -19,12 +75,12 @@ func GetAllBenchmarks(ctx context.Context) ([]models.Benchmark, error) {
 			COALESCE(hardware, '{}'::jsonb),
 			COALESCE(metrics, '{}'::jsonb),
 			version,
-			created_at
-		FROM benchmark
-		ORDER BY id DESC
-	`
+			created_at`+where, args, benchmarkSort)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := pkg.DB.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,17 +104,24 @@ func GetAllBenchmarks(ctx context.Context) ([]models.Benchmark, error) {
 			return nil, err
 		}
 
-		json.Unmarshal(hw, &b.Hardware)
-		json.Unmarshal(mt, &b.Metrics)
+		if err := unmarshalBenchmarkJSON(&b, hw, mt); err != nil {
+			return nil, err
+		}
 
 		benchmarks = append(benchmarks, b)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return benchmarks, rows.Err()
+	return newPage(page, benchmarks, total, benchmarkSortKey), nil
 }
 
 // 按 software_id 获取指定软件的 Benchmark
-func GetBenchmarksBySoftwareID(ctx context.Context, softwareID int) ([]models.Benchmark, error) {
+func (r *PostgresBenchmarkRepository) ListBySoftwareID(ctx context.Context, softwareID int) ([]models.Benchmark, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	query := `
 		SELECT
 			id,
@@ -74,7 +137,7 @@ func GetBenchmarksBySoftwareID(ctx context.Context, softwareID int) ([]models.Be
 		ORDER BY id
 	`
 
-	rows, err := pkg.DB.QueryContext(ctx, query, softwareID)
+	rows, err := r.db.QueryContext(ctx, query, softwareID)
 	if err != nil {
 		return nil, err
 	}
@@ -98,11 +161,146 @@ func GetBenchmarksBySoftwareID(ctx context.Context, softwareID int) ([]models.Be
 			return nil, err
 		}
 
-		json.Unmarshal(hw, &b.Hardware)
-		json.Unmarshal(mt, &b.Metrics)
+		if err := unmarshalBenchmarkJSON(&b, hw, mt); err != nil {
+			return nil, err
+		}
 
 		benchmarks = append(benchmarks, b)
 	}
 
 	return benchmarks, rows.Err()
 }
+
+// 解析 hardware、metrics 两个 JSONB 列
+func unmarshalBenchmarkJSON(b *models.Benchmark, hw, mt []byte) error {
+	if err := json.Unmarshal(hw, &b.Hardware); err != nil {
+		return fmt.Errorf("benchmark %d hardware: %w", b.ID, err)
+	}
+	if err := json.Unmarshal(mt, &b.Metrics); err != nil {
+		return fmt.Errorf("benchmark %d metrics: %w", b.ID, err)
+	}
+	return nil
+}
+
+// isForeignKeyViolation 判断是否违反外键（benchmark.software_id 等）
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// 按 ID 获取单个 Benchmark
+func (r *PostgresBenchmarkRepository) GetByID(ctx context.Context, id int) (*models.Benchmark, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			software_id,
+			name,
+			dataset,
+			COALESCE(hardware, '{}'::jsonb),
+			COALESCE(metrics, '{}'::jsonb),
+			version,
+			created_at
+		FROM benchmark
+		WHERE id = $1
+	`
+
+	var b models.Benchmark
+	var hw, mt []byte
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&b.ID,
+		&b.SoftwareID,
+		&b.Name,
+		&b.Dataset,
+		&hw,
+		&mt,
+		&b.Version,
+		&b.CreatedAt,
+	)
+	if err != nil {
+		return nil, notFoundAs(err, ErrBenchmarkNotFound)
+	}
+
+	if err := unmarshalBenchmarkJSON(&b, hw, mt); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// 新增 Benchmark，回填 id 和 created_at
+func (r *PostgresBenchmarkRepository) Create(ctx context.Context, b *models.Benchmark) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	hw, err := json.Marshal(b.Hardware)
+	if err != nil {
+		return err
+	}
+	mt, err := json.Marshal(b.Metrics)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO benchmark (software_id, name, dataset, hardware, metrics, version)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err = r.db.QueryRowContext(ctx, query,
+		b.SoftwareID, b.Name, b.Dataset, hw, mt, b.Version,
+	).Scan(&b.ID, &b.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrSoftwareNotFound
+	}
+	return err
+}
+
+// 更新 Benchmark，记录不存在时返回 ErrBenchmarkNotFound
+func (r *PostgresBenchmarkRepository) Update(ctx context.Context, b *models.Benchmark) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	hw, err := json.Marshal(b.Hardware)
+	if err != nil {
+		return err
+	}
+	mt, err := json.Marshal(b.Metrics)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE benchmark
+		SET software_id = $1, name = $2, dataset = $3, hardware = $4, metrics = $5, version = $6
+		WHERE id = $7
+		RETURNING created_at
+	`
+	err = r.db.QueryRowContext(ctx, query,
+		b.SoftwareID, b.Name, b.Dataset, hw, mt, b.Version, b.ID,
+	).Scan(&b.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrSoftwareNotFound
+	}
+	return notFoundAs(err, ErrBenchmarkNotFound)
+}
+
+// 删除 Benchmark，记录不存在时返回 ErrBenchmarkNotFound
+func (r *PostgresBenchmarkRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM benchmark WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrBenchmarkNotFound
+	}
+	return nil
+}
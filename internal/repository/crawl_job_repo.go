@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+// 基于 PostgreSQL 的 CrawlJobRepository
+type PostgresCrawlJobRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresCrawlJobRepository(db *sql.DB, queryTimeout time.Duration) *PostgresCrawlJobRepository {
+	return &PostgresCrawlJobRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// 新建抓取任务，由 job.InstanceID 持有、租约为 lease，回填 id、created_at 和租约到期时间
+func (r *PostgresCrawlJobRepository) Create(ctx context.Context, job *models.CrawlJob, lease time.Duration) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	softwares, err := json.Marshal(job.Softwares)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO crawl_job (state, softwares, "full", instance_id, lease_expires_at)
+		VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5))
+		RETURNING id, created_at, lease_expires_at
+	`
+	return r.db.QueryRowContext(ctx, query, job.State, softwares, job.Full, job.InstanceID, lease.Seconds()).
+		Scan(&job.ID, &job.CreatedAt, &job.LeaseExpiresAt)
+}
+
+// 保存任务的状态、进度和计数，离开 queued/running 时释放租约。
+// 任务已不归 job.InstanceID 持有或已结束时不做修改，返回 ErrCrawlJobLeaseLost
+func (r *PostgresCrawlJobRepository) Update(ctx context.Context, job *models.CrawlJob) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	softwares, err := json.Marshal(job.Softwares)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE crawl_job
+		SET state = $1, softwares = $2, found = $3, inserted = $4, updated = $5, skipped = $6, queued = $7, failed = $8,
+		    error = $9, started_at = $10, finished_at = $11,
+		    lease_expires_at = CASE WHEN $1 IN ('queued', 'running') THEN lease_expires_at END
+		WHERE id = $12 AND instance_id = $13 AND state NOT IN ('succeeded', 'failed', 'cancelled')
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		job.State, softwares, job.Found, job.Inserted, job.Updated, job.Skipped, job.Queued, job.Failed,
+		job.Error, job.StartedAt, job.FinishedAt, job.ID, job.InstanceID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrCrawlJobLeaseLost
+	}
+	return nil
+}
+
+const crawlJobColumns = `id, state, softwares, "full", cancel_requested, COALESCE(instance_id, ''), lease_expires_at,
+	found, inserted, updated, skipped, queued, failed, error, created_at, started_at, finished_at`
+
+func scanCrawlJob(row rowScanner) (*models.CrawlJob, error) {
+	var job models.CrawlJob
+	var softwares []byte
+	err := row.Scan(
+		&job.ID, &job.State, &softwares, &job.Full, &job.CancelRequested, &job.InstanceID, &job.LeaseExpiresAt,
+		&job.Found, &job.Inserted, &job.Updated, &job.Skipped, &job.Queued, &job.Failed, &job.Error, &job.CreatedAt, &job.StartedAt, &job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(softwares, &job.Softwares)
+	return &job, nil
+}
+
+// 按 ID 获取抓取任务，不存在时返回 ErrCrawlJobNotFound
+func (r *PostgresCrawlJobRepository) GetByID(ctx context.Context, id int64) (*models.CrawlJob, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	job, err := scanCrawlJob(r.db.QueryRowContext(ctx, `SELECT `+crawlJobColumns+` FROM crawl_job WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFoundAs(err, ErrCrawlJobNotFound)
+	}
+	return job, nil
+}
+
+// 请求取消未结束的任务：排队中和被中断的任务直接标记为已取消，运行中的任务只记录请求，
+// 由执行它的实例在处理完当前页后结束。任务已结束时返回该任务和 ErrCrawlJobFinished
+func (r *PostgresCrawlJobRepository) RequestCancel(ctx context.Context, id int64) (*models.CrawlJob, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	job, err := scanCrawlJob(r.db.QueryRowContext(ctx, `
+		UPDATE crawl_job
+		SET cancel_requested = TRUE,
+		    state = CASE WHEN state IN ('queued', 'interrupted') THEN 'cancelled' ELSE state END,
+		    finished_at = CASE WHEN state IN ('queued', 'interrupted') THEN NOW() ELSE finished_at END,
+		    lease_expires_at = CASE WHEN state IN ('queued', 'interrupted') THEN NULL ELSE lease_expires_at END
+		WHERE id = $1 AND state NOT IN ('succeeded', 'failed', 'cancelled')
+		RETURNING `+crawlJobColumns, id))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return job, err
+	}
+	// 不存在或已结束
+	job, err = r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return job, ErrCrawlJobFinished
+}
+
+// 任务是否已被请求取消
+func (r *PostgresCrawlJobRepository) CancelRequested(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var requested bool
+	err := r.db.QueryRowContext(ctx, `SELECT cancel_requested FROM crawl_job WHERE id = $1`, id).Scan(&requested)
+	return requested, notFoundAs(err, ErrCrawlJobNotFound)
+}
+
+// 续约 instanceID 持有的排队中和运行中的任务，租约延长到 lease 之后
+func (r *PostgresCrawlJobRepository) RenewLeases(ctx context.Context, instanceID string, lease time.Duration) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE crawl_job SET lease_expires_at = NOW() + make_interval(secs => $2)
+		WHERE instance_id = $1 AND state IN ('queued', 'running')
+	`, instanceID, lease.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// 持有实例异常退出、租约已过期的排队中和运行中的任务不会再执行，标记为失败（已请求取消的标记为已取消）；
+// 其他实例仍在续约的任务不受影响，正常退出时中断的任务是 interrupted，也不在此列
+func (r *PostgresCrawlJobRepository) FailExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE crawl_job
+		SET state = CASE WHEN cancel_requested THEN 'cancelled' ELSE 'failed' END,
+		    error = CASE WHEN cancel_requested THEN error ELSE 'unavailable: the instance running this job stopped unexpectedly' END,
+		    finished_at = NOW(), lease_expires_at = NULL
+		WHERE state IN ('queued', 'running') AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// 认领最多 limit 个被中断且没有有效租约的任务，改回 queued 并由 instanceID 持有、租约为 lease，按 ID 升序返回；
+// 多个实例同时启动时每个任务只会被一个实例认领
+func (r *PostgresCrawlJobRepository) ClaimInterrupted(ctx context.Context, instanceID string, lease time.Duration, limit int) ([]*models.CrawlJob, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE crawl_job
+		SET state = 'queued', instance_id = $2, lease_expires_at = NOW() + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT id FROM crawl_job
+			WHERE state = 'interrupted' AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+			ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+crawlJobColumns, limit, instanceID, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.CrawlJob
+	for rows.Next() {
+		job, err := scanCrawlJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(jobs, func(a, b *models.CrawlJob) int { return cmp.Compare(a.ID, b.ID) })
+	return jobs, nil
+}
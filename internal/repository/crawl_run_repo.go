@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+// 基于 PostgreSQL 的 CrawlRunRepository
+type PostgresCrawlRunRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresCrawlRunRepository(db *sql.DB, queryTimeout time.Duration) *PostgresCrawlRunRepository {
+	return &PostgresCrawlRunRepository{db: db, queryTimeout: queryTimeout}
+}
+
+var crawlRunSort = sortSpec{
+	columns: map[string]sortColumn{
+		"id":         {expr: "id", cast: "::bigint"},
+		"started_at": {expr: "started_at", cast: "::timestamp"},
+	},
+	defaultSort: "id",
+	defaultDesc: true,
+	id:          sortColumn{expr: "id", cast: "::bigint"},
+}
+
+func crawlRunSortKey(r models.CrawlRun, sort string) (string, string) {
+	id := strconv.FormatInt(r.ID, 10)
+	if sort == "started_at" {
+		return r.StartedAt.Format(time.RFC3339Nano), id
+	}
+	return id, id
+}
+
+const crawlRunColumns = `id, state, job_id, error, started_at, finished_at`
+
+func scanCrawlRun(row rowScanner) (models.CrawlRun, error) {
+	var r models.CrawlRun
+	err := row.Scan(&r.ID, &r.State, &r.JobID, &r.Error, &r.StartedAt, &r.FinishedAt)
+	return r, err
+}
+
+// 新建定时抓取记录，回填 id 和 started_at
+func (r *PostgresCrawlRunRepository) Create(ctx context.Context, run *models.CrawlRun) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO crawl_run (state, job_id, error)
+		VALUES ($1, $2, $3)
+		RETURNING id, started_at
+	`
+	return r.db.QueryRowContext(ctx, query, run.State, run.JobID, run.Error).Scan(&run.ID, &run.StartedAt)
+}
+
+// 保存定时抓取的状态和结果
+func (r *PostgresCrawlRunRepository) Update(ctx context.Context, run *models.CrawlRun) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE crawl_run
+		SET state = $1, job_id = $2, error = $3, finished_at = $4
+		WHERE id = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, run.State, run.JobID, run.Error, run.FinishedAt, run.ID)
+	return err
+}
+
+// 定时抓取记录列表（分页）
+func (r *PostgresCrawlRunRepository) Query(ctx context.Context, page PageRequest) (*Page[models.CrawlRun], error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	where := `
+		FROM crawl_run
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	total, err := countRows(ctx, r.db, "SELECT COUNT(*)"+where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := page.apply("SELECT "+crawlRunColumns+where, args, crawlRunSort)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.CrawlRun
+	for rows.Next() {
+		run, err := scanCrawlRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newPage(page, runs, total, crawlRunSortKey), nil
+}
+
+// 最近一次执行记录，state 为空时不限状态；没有记录时返回 sql.ErrNoRows
+func (r *PostgresCrawlRunRepository) GetLatest(ctx context.Context, state string) (*models.CrawlRun, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + crawlRunColumns + `
+		FROM crawl_run
+		WHERE $1 = '' OR state = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	run, err := scanCrawlRun(r.db.QueryRowContext(ctx, query, state))
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// 把没有结束的执行记录标记为失败，只应在持有定时抓取锁（确认没有实例在执行）时调用
+func (r *PostgresCrawlRunRepository) FailUnfinished(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE crawl_run
+		SET state = 'failed', error = 'interrupted by server restart', finished_at = NOW()
+		WHERE finished_at IS NULL
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+// 基于 PostgreSQL 的 CrawlStateRepository
+type PostgresCrawlStateRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresCrawlStateRepository(db *sql.DB, queryTimeout time.Duration) *PostgresCrawlStateRepository {
+	return &PostgresCrawlStateRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// 按软件名获取抓取状态，从未抓取过时返回 sql.ErrNoRows
+func (r *PostgresCrawlStateRepository) Get(ctx context.Context, softwareName string) (*models.SoftwareCrawlState, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT cs.software_id, cs.last_crawled_at, cs.last_success_at, cs.newest_arxiv_id
+		FROM software_crawl_state cs
+		JOIN software s ON s.id = cs.software_id
+		WHERE LOWER(s.name) = LOWER($1)
+	`
+	var state models.SoftwareCrawlState
+	err := r.db.QueryRowContext(ctx, query, softwareName).Scan(
+		&state.SoftwareID, &state.LastCrawledAt, &state.LastSuccessAt, &state.NewestArxivID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// 保存软件的抓取状态；软件已被删除时什么也不做
+func (r *PostgresCrawlStateRepository) Save(ctx context.Context, softwareName string, state *models.SoftwareCrawlState) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO software_crawl_state (software_id, last_crawled_at, last_success_at, newest_arxiv_id)
+		SELECT id, $2, $3, $4 FROM software WHERE LOWER(name) = LOWER($1)
+		ON CONFLICT (software_id) DO UPDATE
+		SET last_crawled_at = EXCLUDED.last_crawled_at,
+		    last_success_at = EXCLUDED.last_success_at,
+		    newest_arxiv_id = EXCLUDED.newest_arxiv_id
+	`
+	_, err := r.db.ExecContext(ctx, query, softwareName, state.LastCrawledAt, state.LastSuccessAt, state.NewestArxivID)
+	return err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"hpc-site/internal/apperr"
+)
+
+// 各存储返回的“不存在”错误，都包装了 sql.ErrNoRows，errors.Is(err, sql.ErrNoRows) 仍然成立
+var (
+	ErrSoftwareNotFound        = apperr.Wrap(apperr.NotFound, "software not found", sql.ErrNoRows)
+	ErrPaperNotFound           = apperr.Wrap(apperr.NotFound, "paper not found", sql.ErrNoRows)
+	ErrPaperOrSoftwareNotFound = apperr.Wrap(apperr.NotFound, "paper or software not found", sql.ErrNoRows)
+	ErrBenchmarkNotFound       = apperr.Wrap(apperr.NotFound, "benchmark not found", sql.ErrNoRows)
+	ErrReviewNotFound          = apperr.Wrap(apperr.NotFound, "review not found", sql.ErrNoRows)
+	ErrUserNotFound            = apperr.Wrap(apperr.NotFound, "user not found", sql.ErrNoRows)
+	ErrAPIKeyNotFound          = apperr.Wrap(apperr.NotFound, "api key not found", sql.ErrNoRows)
+	ErrCrawlJobNotFound        = apperr.Wrap(apperr.NotFound, "crawl job not found", sql.ErrNoRows)
+)
+
+var ErrCrawlJobFinished = apperr.New(apperr.Conflict, "crawl job already finished")
+
+// 保存任务时任务已不归本实例持有：租约过期后被其他实例标记为失败或认领，或排队时已被取消
+var ErrCrawlJobLeaseLost = apperr.New(apperr.Conflict, "crawl job is no longer owned by this instance")
+
+// 把 sql.ErrNoRows 转换为 notFound，其余错误原样返回
+func notFoundAs(err error, notFound *apperr.Error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+	return err
+}
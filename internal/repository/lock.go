@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// 基于 PostgreSQL advisory lock 的 Locker
+type PostgresLocker struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresLocker(db *sql.DB, queryTimeout time.Duration) *PostgresLocker {
+	return &PostgresLocker{db: db, queryTimeout: queryTimeout}
+}
+
+// 尝试获取 PostgreSQL 会话级 advisory lock，拿不到时立即返回 ok=false。
+// 会话锁绑定在连接上，因此占用一个专用连接直到 release；进程退出、连接断开时锁自动释放
+func (r *PostgresLocker) TryLock(ctx context.Context, key int64) (release func(), ok bool, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+	release = func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("释放 advisory lock %d 失败: %v", key, err)
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
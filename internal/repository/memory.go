@@ -0,0 +1,1248 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+// 内存中的存储，供测试和本地演示模式使用。
+// 与 PostgreSQL 实现保持相同的语义：软件名唯一、有 Benchmark 的软件不能删除、删除软件时级联删除论文关联、
+// 审核记录和抓取状态、分页和排序规则一致
+type memoryStore struct {
+	mu          sync.RWMutex
+	software    map[int]models.Software
+	papers      map[string]models.Paper // SoftwareNames、SoftwareConfidence 不在这里存储，读取时由 links 生成
+	links       map[string][]memoryLink // 论文 ID → 关联的软件
+	benchmarks  map[int]models.Benchmark
+	users       map[int]models.User
+	apiKeys     map[int]memoryAPIKey
+	reviews     map[int64]models.PaperReview // PaperTitle、SoftwareName 读取时生成
+	crawlJobs   map[int64]models.CrawlJob
+	crawlRuns   map[int64]models.CrawlRun
+	crawlStates map[int]models.SoftwareCrawlState // 软件 ID → 抓取状态
+	locks       map[int64]bool
+
+	nextSoftwareID  int
+	nextBenchmarkID int
+	nextUserID      int
+	nextAPIKeyID    int
+	nextReviewID    int64
+	nextCrawlJobID  int64
+	nextCrawlRunID  int64
+}
+
+type memoryAPIKey struct {
+	models.APIKey
+	hash string
+}
+
+type memoryLink struct {
+	softwareID int
+	source     string
+	confidence *float64
+	createdAt  time.Time
+}
+
+// 基于内存的存储，进程退出后数据丢失
+func NewMemory() Repositories {
+	m := &memoryStore{
+		software:    make(map[int]models.Software),
+		papers:      make(map[string]models.Paper),
+		links:       make(map[string][]memoryLink),
+		benchmarks:  make(map[int]models.Benchmark),
+		users:       make(map[int]models.User),
+		apiKeys:     make(map[int]memoryAPIKey),
+		reviews:     make(map[int64]models.PaperReview),
+		crawlJobs:   make(map[int64]models.CrawlJob),
+		crawlRuns:   make(map[int64]models.CrawlRun),
+		crawlStates: make(map[int]models.SoftwareCrawlState),
+		locks:       make(map[int64]bool),
+	}
+	return Repositories{
+		Software:   &memorySoftwareRepository{m},
+		Papers:     &memoryPaperRepository{m},
+		Benchmarks: &memoryBenchmarkRepository{m},
+		Users:      &memoryUserRepository{m},
+		Reviews:    &memoryReviewRepository{m},
+		Search:     &memorySearchRepository{m},
+		CrawlJobs:  &memoryCrawlJobRepository{m},
+		CrawlRuns:  &memoryCrawlRunRepository{m},
+		CrawlState: &memoryCrawlStateRepository{m},
+		Locks:      &memoryLocker{m},
+	}
+}
+
+// 内存实现的分页，与 PageRequest.apply + newPage 的结果一致；会对 items 原地排序
+func paginate[T any](items []T, page PageRequest, spec sortSpec, key func(T, string) (string, string)) (*Page[T], error) {
+	col, err := page.resolve(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	compare := func(value, id string, other T) int {
+		ov, oid := key(other, page.Sort)
+		c := compareSortValues(value, ov, col.cast)
+		if c == 0 {
+			c = compareSortValues(id, oid, spec.id.cast)
+		}
+		if page.Desc {
+			c = -c
+		}
+		return c
+	}
+	slices.SortFunc(items, func(a, b T) int {
+		value, id := key(a, page.Sort)
+		return compare(value, id, b)
+	})
+	total := len(items)
+
+	if page.Cursor != "" {
+		cur, err := decodeCursor(page.Cursor)
+		if err != nil || cur.Sort != page.Sort || cur.Desc != page.Desc {
+			return nil, ErrInvalidCursor
+		}
+		// 跳过游标所在行及其之前的行
+		i := 0
+		for i < len(items) && compare(cur.Value, cur.ID, items[i]) >= 0 {
+			i++
+		}
+		items = items[i:]
+		page.Offset = 0
+	}
+
+	items = items[min(page.Offset, len(items)):]
+	if page.Limit > 0 && len(items) > page.Limit+1 {
+		items = items[:page.Limit+1]
+	}
+	return newPage(page, items, total, key), nil
+}
+
+// 按排序列的类型比较游标值
+func compareSortValues(a, b, cast string) int {
+	switch cast {
+	case "::int", "::bigint":
+		x, _ := strconv.ParseInt(a, 10, 64)
+		y, _ := strconv.ParseInt(b, 10, 64)
+		return cmp.Compare(x, y)
+	case "::real":
+		x, _ := strconv.ParseFloat(a, 64)
+		y, _ := strconv.ParseFloat(b, 64)
+		return cmp.Compare(x, y)
+	case "::timestamp":
+		x, _ := time.Parse(time.RFC3339Nano, a)
+		y, _ := time.Parse(time.RFC3339Nano, b)
+		return x.Compare(y)
+	}
+	return strings.Compare(a, b)
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, s) })
+}
+
+func cloneSoftware(s models.Software) models.Software {
+	s.Categories = slices.Clone(s.Categories)
+	s.Tags = slices.Clone(s.Tags)
+	s.Aliases = slices.Clone(nonNil(s.Aliases))
+	s.ExcludeTerms = slices.Clone(nonNil(s.ExcludeTerms))
+	return s
+}
+
+type memorySoftwareRepository struct {
+	m *memoryStore
+}
+
+func (r *memorySoftwareRepository) Query(ctx context.Context, filter SoftwareFilter, page PageRequest) (*Page[models.Software], error) {
+	r.m.mu.RLock()
+	var softwares []models.Software
+	for _, s := range r.m.software {
+		if filter.Name != "" && !strings.EqualFold(s.Name, filter.Name) {
+			continue
+		}
+		if filter.Category != "" && !containsFold(s.Categories, filter.Category) {
+			continue
+		}
+		if filter.Tag != "" && !containsFold(s.Tags, filter.Tag) {
+			continue
+		}
+		if filter.Search != "" && !containsIgnoreCase(s.Name, filter.Search) && !containsIgnoreCase(s.Abstract, filter.Search) {
+			continue
+		}
+		softwares = append(softwares, cloneSoftware(s))
+	}
+	r.m.mu.RUnlock()
+	return paginate(softwares, page, softwareSort, softwareSortKey)
+}
+
+func containsIgnoreCase(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (r *memorySoftwareRepository) GetByID(ctx context.Context, id int) (*models.Software, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	s, ok := r.m.software[id]
+	if !ok {
+		return nil, ErrSoftwareNotFound
+	}
+	s = cloneSoftware(s)
+	return &s, nil
+}
+
+func (r *memorySoftwareRepository) GetByName(ctx context.Context, name string) (*models.Software, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	s, ok := r.m.softwareByName(name)
+	if !ok {
+		return nil, ErrSoftwareNotFound
+	}
+	s = cloneSoftware(s)
+	return &s, nil
+}
+
+// 按名称查找软件，与 software_name_lower_idx 一致不区分大小写。调用方需持有锁
+func (m *memoryStore) softwareByName(name string) (models.Software, bool) {
+	for _, s := range m.software {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return models.Software{}, false
+}
+
+func (r *memorySoftwareRepository) Create(ctx context.Context, s *models.Software) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.softwareByName(s.Name); ok {
+		return ErrSoftwareNameConflict
+	}
+	r.m.nextSoftwareID++
+	s.ID = r.m.nextSoftwareID
+	s.CreatedAt = time.Now()
+	r.m.software[s.ID] = cloneSoftware(*s)
+	return nil
+}
+
+func (r *memorySoftwareRepository) Update(ctx context.Context, s *models.Software) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	old, ok := r.m.software[s.ID]
+	if !ok {
+		return ErrSoftwareNotFound
+	}
+	if other, ok := r.m.softwareByName(s.Name); ok && other.ID != s.ID {
+		return ErrSoftwareNameConflict
+	}
+	s.CreatedAt = old.CreatedAt
+	r.m.software[s.ID] = cloneSoftware(*s)
+	return nil
+}
+
+func (r *memorySoftwareRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.software[id]; !ok {
+		return ErrSoftwareNotFound
+	}
+	// 与 benchmark 外键的 ON DELETE RESTRICT，paper_software、paper_review、software_crawl_state 外键的 ON DELETE CASCADE 一致
+	for _, b := range r.m.benchmarks {
+		if b.SoftwareID == id {
+			return ErrSoftwareHasBenchmarks
+		}
+	}
+	delete(r.m.software, id)
+	for paperID, links := range r.m.links {
+		r.m.links[paperID] = slices.DeleteFunc(links, func(l memoryLink) bool { return l.softwareID == id })
+	}
+	maps.DeleteFunc(r.m.reviews, func(_ int64, rv models.PaperReview) bool { return rv.SoftwareID == id })
+	delete(r.m.crawlStates, id)
+	return nil
+}
+
+type memoryPaperRepository struct {
+	m *memoryStore
+}
+
+// 生成带关联软件名和置信度的论文，调用方需持有锁
+func (m *memoryStore) paperView(p models.Paper) models.Paper {
+	links := slices.Clone(m.links[p.ID])
+	slices.SortFunc(links, func(a, b memoryLink) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(m.software[a.softwareID].Name, m.software[b.softwareID].Name)
+	})
+	p.Authors = slices.Clone(p.Authors)
+	p.Categories = slices.Clone(p.Categories)
+	p.ExternalIDs = maps.Clone(p.ExternalIDs)
+	p.SoftwareNames = []string{}
+	p.SoftwareConfidence = map[string]float64{}
+	for _, l := range links {
+		name := m.software[l.softwareID].Name
+		p.SoftwareNames = append(p.SoftwareNames, name)
+		if l.confidence != nil {
+			p.SoftwareConfidence[name] = *l.confidence
+		}
+	}
+	return p
+}
+
+// 与 paperSourceColumns 一致：来源默认为 arxiv，external_ids 不为 nil；关联单独存储
+func storedPaper(p models.Paper) models.Paper {
+	if p.Source == "" {
+		p.Source = "arxiv"
+	}
+	p.ExternalIDs = maps.Clone(p.ExternalIDs)
+	if p.ExternalIDs == nil {
+		p.ExternalIDs = map[string]string{}
+	}
+	p.Authors = slices.Clone(p.Authors)
+	p.Categories = slices.Clone(p.Categories)
+	p.SoftwareNames = nil
+	p.SoftwareConfidence = nil
+	return p
+}
+
+// 关联论文和软件，已关联时只更新置信度；调用方需持有锁
+func (m *memoryStore) link(paperID string, softwareID int, source string, confidence *float64) {
+	links := m.links[paperID]
+	for i := range links {
+		if links[i].softwareID == softwareID {
+			if confidence != nil {
+				links[i].confidence = confidence
+			}
+			return
+		}
+	}
+	m.links[paperID] = append(links, memoryLink{
+		softwareID: softwareID,
+		source:     source,
+		confidence: confidence,
+		createdAt:  time.Now(),
+	})
+}
+
+func (r *memoryPaperRepository) Query(ctx context.Context, filter PaperFilter, page PageRequest) (*Page[models.Paper], error) {
+	r.m.mu.RLock()
+	var papers []models.Paper
+	for _, stored := range r.m.papers {
+		p := r.m.paperView(stored)
+		if len(p.SoftwareNames) == 0 {
+			continue
+		}
+		if filter.Software != "" && !containsFold(p.SoftwareNames, filter.Software) {
+			continue
+		}
+		if filter.Author != "" && !slices.ContainsFunc(p.Authors, func(a string) bool { return containsIgnoreCase(a, filter.Author) }) {
+			continue
+		}
+		if filter.From != nil || filter.To != nil {
+			date, ok := publishedDate(p.PublishedTime)
+			if !ok || (filter.From != nil && date.Before(*filter.From)) || (filter.To != nil && date.After(*filter.To)) {
+				continue
+			}
+		}
+		if filter.Search != "" && !containsIgnoreCase(p.Title, filter.Search) && !containsIgnoreCase(p.Abstract, filter.Search) {
+			continue
+		}
+		if filter.Withdrawn != nil && p.Withdrawn != *filter.Withdrawn {
+			continue
+		}
+		papers = append(papers, p)
+	}
+	r.m.mu.RUnlock()
+	return paginate(papers, page, paperSort, paperSortKey)
+}
+
+func (r *memoryPaperRepository) GetByID(ctx context.Context, id string) (*models.Paper, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	p, ok := r.m.papers[id]
+	if !ok {
+		return nil, ErrPaperNotFound
+	}
+	p = r.m.paperView(p)
+	return &p, nil
+}
+
+func (r *memoryPaperRepository) GetBySoftwareID(ctx context.Context, softwareID int) ([]models.Paper, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	type linked struct {
+		paper     models.Paper
+		createdAt time.Time
+	}
+	var found []linked
+	for paperID, links := range r.m.links {
+		for _, l := range links {
+			if l.softwareID == softwareID {
+				found = append(found, linked{r.m.paperView(r.m.papers[paperID]), l.createdAt})
+			}
+		}
+	}
+	// 与 PostgreSQL 实现一致：最近关联的在前
+	slices.SortFunc(found, func(a, b linked) int {
+		if c := b.createdAt.Compare(a.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.paper.ID, b.paper.ID)
+	})
+	var papers []models.Paper
+	for _, f := range found {
+		papers = append(papers, f.paper)
+	}
+	return papers, nil
+}
+
+func (r *memoryPaperRepository) Upsert(ctx context.Context, paper models.Paper) error {
+	_, err := r.UpsertBatch(ctx, []models.Paper{paper}, nil)
+	return err
+}
+
+// 与 upsertPaperSQL 的合并规则一致，reviews 在对应论文写入后放入审核队列
+func (r *memoryPaperRepository) UpsertBatch(ctx context.Context, papers []models.Paper, reviews []models.PaperReview) (map[string]error, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var failed map[string]error
+	for _, paper := range papers {
+		// 与 paper_review 的外键一致，审核记录写不进去时这一篇整篇跳过
+		var paperReviews []models.PaperReview
+		for _, rv := range reviews {
+			if rv.PaperID == paper.ID {
+				paperReviews = append(paperReviews, rv)
+			}
+		}
+		missing := slices.ContainsFunc(paperReviews, func(rv models.PaperReview) bool {
+			_, ok := r.m.software[rv.SoftwareID]
+			return !ok
+		})
+		if missing {
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[paper.ID] = fmt.Errorf("queue review for paper %s: %w", paper.ID, ErrPaperOrSoftwareNotFound)
+			continue
+		}
+
+		stored := storedPaper(paper)
+		if old, ok := r.m.papers[paper.ID]; ok {
+			stored.CreatedAt = old.CreatedAt
+			if stored.DOI == "" {
+				stored.DOI = old.DOI
+			}
+			if stored.JournalRef == "" {
+				stored.JournalRef = old.JournalRef
+			}
+			if len(stored.Authors) == 0 {
+				stored.Authors = old.Authors
+			}
+			if stored.Abstract == "" {
+				stored.Abstract = old.Abstract
+			}
+			if stored.Pdf == "" {
+				stored.Pdf = old.Pdf
+			}
+			if stored.PublishedTime == "" {
+				stored.PublishedTime = old.PublishedTime
+			}
+			merged := maps.Clone(old.ExternalIDs)
+			maps.Copy(merged, stored.ExternalIDs)
+			stored.ExternalIDs = merged
+		} else {
+			stored.CreatedAt = time.Now()
+		}
+		r.m.papers[paper.ID] = stored
+
+		for _, name := range paper.SoftwareNames {
+			s, ok := r.m.softwareByName(name)
+			if !ok {
+				continue
+			}
+			var confidence *float64
+			if c, ok := paper.SoftwareConfidence[name]; ok {
+				confidence = &c
+			}
+			r.m.link(paper.ID, s.ID, LinkSourceCrawl, confidence)
+		}
+		for _, rv := range paperReviews {
+			r.m.queueReview(rv)
+		}
+	}
+	return failed, nil
+}
+
+func (r *memoryPaperRepository) UpdateMetadata(ctx context.Context, paper models.Paper) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	old, ok := r.m.papers[paper.ID]
+	if !ok {
+		return ErrPaperNotFound
+	}
+	stored := storedPaper(paper)
+	stored.CreatedAt = old.CreatedAt
+	r.m.papers[paper.ID] = stored
+	return nil
+}
+
+func (r *memoryPaperRepository) LinkSoftware(ctx context.Context, paperID string, softwareID int, source string, confidence float64) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.papers[paperID]; !ok {
+		return ErrPaperOrSoftwareNotFound
+	}
+	if _, ok := r.m.software[softwareID]; !ok {
+		return ErrPaperOrSoftwareNotFound
+	}
+	r.m.link(paperID, softwareID, source, &confidence)
+	return nil
+}
+
+// 调用方需持有锁
+func (m *memoryStore) existingPaper(p models.Paper) ExistingPaper {
+	e := ExistingPaper{ID: p.ID, Title: p.Title, Abstract: p.Abstract}
+	for _, l := range m.links[p.ID] {
+		e.Linked = append(e.Linked, int64(l.softwareID))
+	}
+	for _, rv := range m.reviews {
+		if rv.PaperID == p.ID {
+			e.Reviewed = append(e.Reviewed, int64(rv.SoftwareID))
+		}
+	}
+	return e
+}
+
+func (r *memoryPaperRepository) GetExisting(ctx context.Context, ids []string) (map[string]ExistingPaper, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	result := make(map[string]ExistingPaper, len(ids))
+	for _, id := range ids {
+		if p, ok := r.m.papers[id]; ok {
+			result[id] = r.m.existingPaper(p)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryPaperRepository) GetExistingByDOI(ctx context.Context, dois []string) (map[string]ExistingPaper, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	wanted := make(map[string]bool, len(dois))
+	for _, d := range dois {
+		wanted[strings.ToLower(d)] = true
+	}
+	result := make(map[string]ExistingPaper)
+	for _, p := range r.m.papers {
+		if doi := strings.ToLower(p.DOI); doi != "" && wanted[doi] {
+			result[doi] = r.m.existingPaper(p)
+		}
+	}
+	return result, nil
+}
+
+type memoryBenchmarkRepository struct {
+	m *memoryStore
+}
+
+func cloneBenchmark(b models.Benchmark) models.Benchmark {
+	b.Hardware = maps.Clone(b.Hardware)
+	b.Metrics = maps.Clone(b.Metrics)
+	return b
+}
+
+func (r *memoryBenchmarkRepository) List(ctx context.Context, page PageRequest) (*Page[models.Benchmark], error) {
+	r.m.mu.RLock()
+	benchmarks := make([]models.Benchmark, 0, len(r.m.benchmarks))
+	for _, b := range r.m.benchmarks {
+		benchmarks = append(benchmarks, cloneBenchmark(b))
+	}
+	r.m.mu.RUnlock()
+	return paginate(benchmarks, page, benchmarkSort, benchmarkSortKey)
+}
+
+func (r *memoryBenchmarkRepository) ListBySoftwareID(ctx context.Context, softwareID int) ([]models.Benchmark, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var benchmarks []models.Benchmark
+	for _, b := range r.m.benchmarks {
+		if b.SoftwareID == softwareID {
+			benchmarks = append(benchmarks, cloneBenchmark(b))
+		}
+	}
+	slices.SortFunc(benchmarks, func(a, b models.Benchmark) int { return cmp.Compare(a.ID, b.ID) })
+	return benchmarks, nil
+}
+
+func (r *memoryBenchmarkRepository) GetByID(ctx context.Context, id int) (*models.Benchmark, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	b, ok := r.m.benchmarks[id]
+	if !ok {
+		return nil, ErrBenchmarkNotFound
+	}
+	b = cloneBenchmark(b)
+	return &b, nil
+}
+
+func (r *memoryBenchmarkRepository) Create(ctx context.Context, b *models.Benchmark) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.software[b.SoftwareID]; !ok {
+		return ErrSoftwareNotFound
+	}
+	r.m.nextBenchmarkID++
+	b.ID = r.m.nextBenchmarkID
+	b.CreatedAt = time.Now()
+	r.m.benchmarks[b.ID] = cloneBenchmark(*b)
+	return nil
+}
+
+func (r *memoryBenchmarkRepository) Update(ctx context.Context, b *models.Benchmark) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	old, ok := r.m.benchmarks[b.ID]
+	if !ok {
+		return ErrBenchmarkNotFound
+	}
+	if _, ok := r.m.software[b.SoftwareID]; !ok {
+		return ErrSoftwareNotFound
+	}
+	b.CreatedAt = old.CreatedAt
+	r.m.benchmarks[b.ID] = cloneBenchmark(*b)
+	return nil
+}
+
+func (r *memoryBenchmarkRepository) Delete(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.benchmarks[id]; !ok {
+		return ErrBenchmarkNotFound
+	}
+	delete(r.m.benchmarks, id)
+	return nil
+}
+
+type memoryUserRepository struct{ m *memoryStore }
+
+func (r *memoryUserRepository) List(ctx context.Context) ([]models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	users := slices.Collect(maps.Values(r.m.users))
+	slices.SortFunc(users, func(a, b models.User) int { return cmp.Compare(a.ID, b.ID) })
+	return users, nil
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	u, ok := r.m.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (r *memoryUserRepository) GetByName(ctx context.Context, name string) (*models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, u := range r.m.users {
+		if u.Name == name {
+			return &u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, u *models.User) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, other := range r.m.users {
+		if other.Name == u.Name {
+			return ErrUserNameConflict
+		}
+	}
+	r.m.nextUserID++
+	u.ID = r.m.nextUserID
+	u.CreatedAt = time.Now()
+	r.m.users[u.ID] = *u
+	return nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, u *models.User) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	old, ok := r.m.users[u.ID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	old.Role, old.Disabled = u.Role, u.Disabled
+	r.m.users[u.ID] = old
+	*u = old
+	return nil
+}
+
+func (r *memoryUserRepository) ListAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	keys := []models.APIKey{}
+	for _, k := range r.m.apiKeys {
+		if k.UserID == userID {
+			keys = append(keys, k.APIKey)
+		}
+	}
+	slices.SortFunc(keys, func(a, b models.APIKey) int { return cmp.Compare(a.ID, b.ID) })
+	return keys, nil
+}
+
+func (r *memoryUserRepository) CreateAPIKey(ctx context.Context, k *models.APIKey, hash string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.users[k.UserID]; !ok {
+		return ErrUserNotFound
+	}
+	for _, other := range r.m.apiKeys {
+		if other.hash == hash {
+			return ErrAPIKeyConflict
+		}
+	}
+	r.m.nextAPIKeyID++
+	k.ID = r.m.nextAPIKeyID
+	k.CreatedAt = time.Now()
+	r.m.apiKeys[k.ID] = memoryAPIKey{APIKey: *k, hash: hash}
+	return nil
+}
+
+func (r *memoryUserRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	k, ok := r.m.apiKeys[id]
+	if !ok || k.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	r.m.apiKeys[id] = k
+	return nil
+}
+
+func (r *memoryUserRepository) Authenticate(ctx context.Context, hash string) (*models.User, int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for id, k := range r.m.apiKeys {
+		if k.hash != hash || k.RevokedAt != nil {
+			continue
+		}
+		u, ok := r.m.users[k.UserID]
+		if !ok || u.Disabled {
+			break
+		}
+		now := time.Now()
+		k.LastUsedAt = &now
+		r.m.apiKeys[id] = k
+		return &u, id, nil
+	}
+	return nil, 0, ErrAPIKeyNotFound
+}
+
+type memoryReviewRepository struct{ m *memoryStore }
+
+// 放入审核队列，已有记录时不做修改；调用方需持有锁并确认论文和软件存在
+func (m *memoryStore) queueReview(rv models.PaperReview) {
+	for _, other := range m.reviews {
+		if other.PaperID == rv.PaperID && other.SoftwareID == rv.SoftwareID {
+			return
+		}
+	}
+	m.nextReviewID++
+	m.reviews[m.nextReviewID] = models.PaperReview{
+		ID:         m.nextReviewID,
+		PaperID:    rv.PaperID,
+		SoftwareID: rv.SoftwareID,
+		Confidence: float64(float32(rv.Confidence)), // 与 REAL 列的精度一致
+		Reasons:    slices.Clone(nonNil(rv.Reasons)),
+		Status:     models.ReviewPending,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// 带论文标题和软件名的审核记录，调用方需持有锁
+func (m *memoryStore) reviewView(rv models.PaperReview) models.PaperReview {
+	rv.PaperTitle = m.papers[rv.PaperID].Title
+	rv.SoftwareName = m.software[rv.SoftwareID].Name
+	rv.Reasons = slices.Clone(rv.Reasons)
+	return rv
+}
+
+func (r *memoryReviewRepository) Queue(ctx context.Context, rv *models.PaperReview) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.papers[rv.PaperID]; !ok {
+		return ErrPaperOrSoftwareNotFound
+	}
+	if _, ok := r.m.software[rv.SoftwareID]; !ok {
+		return ErrPaperOrSoftwareNotFound
+	}
+	r.m.queueReview(*rv)
+	return nil
+}
+
+func (r *memoryReviewRepository) Query(ctx context.Context, status, software string, page PageRequest) (*Page[models.PaperReview], error) {
+	r.m.mu.RLock()
+	var reviews []models.PaperReview
+	for _, rv := range r.m.reviews {
+		rv = r.m.reviewView(rv)
+		if status != "" && rv.Status != status {
+			continue
+		}
+		if software != "" && !strings.EqualFold(rv.SoftwareName, software) {
+			continue
+		}
+		reviews = append(reviews, rv)
+	}
+	r.m.mu.RUnlock()
+	return paginate(reviews, page, reviewSort, reviewSortKey)
+}
+
+func (r *memoryReviewRepository) GetByID(ctx context.Context, id int64) (*models.PaperReview, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	rv, ok := r.m.reviews[id]
+	if !ok {
+		return nil, ErrReviewNotFound
+	}
+	rv = r.m.reviewView(rv)
+	return &rv, nil
+}
+
+func (r *memoryReviewRepository) Resolve(ctx context.Context, id int64, approve bool) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	rv, ok := r.m.reviews[id]
+	if !ok {
+		return ErrReviewNotFound
+	}
+	if rv.Status != models.ReviewPending {
+		return ErrReviewDone
+	}
+	rv.Status = models.ReviewRejected
+	if approve {
+		rv.Status = models.ReviewApproved
+		confidence := rv.Confidence
+		r.m.link(rv.PaperID, rv.SoftwareID, LinkSourceReview, &confidence)
+	}
+	now := time.Now()
+	rv.ReviewedAt = &now
+	r.m.reviews[id] = rv
+	return nil
+}
+
+// 内存实现的检索不做分词和词干化：查询按空白拆成词，每个词（不区分大小写）都出现在标题或正文中才算命中，
+// 相关度为各词在标题中出现记 2 分、在正文中出现记 1 分之和；摘要为转义后的正文，命中的词以 <mark> 标记
+type memorySearchRepository struct{ m *memoryStore }
+
+type memorySearchDoc struct {
+	typ, id, title, body string
+}
+
+// 调用方需持有锁
+func (m *memoryStore) searchDocs(typ string) []memorySearchDoc {
+	var docs []memorySearchDoc
+	switch typ {
+	case "software":
+		for _, s := range m.software {
+			body := strings.TrimSpace(s.Abstract + " " + strings.Join(s.Tags, " "))
+			docs = append(docs, memorySearchDoc{typ, strconv.Itoa(s.ID), s.Name, body})
+		}
+	case "paper":
+		for _, p := range m.papers {
+			if len(m.links[p.ID]) == 0 {
+				continue
+			}
+			body := strings.TrimSpace(p.Abstract + " " + strings.Join(p.Authors, ", "))
+			docs = append(docs, memorySearchDoc{typ, p.ID, p.Title, body})
+		}
+	case "benchmark":
+		for _, b := range m.benchmarks {
+			docs = append(docs, memorySearchDoc{typ, strconv.Itoa(b.ID), b.Name, b.Dataset})
+		}
+	}
+	return docs
+}
+
+func (r *memorySearchRepository) Search(ctx context.Context, q string, types []string, limit, offset int) ([]models.SearchResult, int, error) {
+	if len(types) == 0 {
+		types = SearchTypes
+	}
+	terms := strings.Fields(strings.ToLower(q))
+
+	r.m.mu.RLock()
+	var results []models.SearchResult
+	for _, t := range types {
+		if !slices.Contains(SearchTypes, t) {
+			r.m.mu.RUnlock()
+			return nil, 0, fmt.Errorf("unknown search type: %s", t)
+		}
+		for _, d := range r.m.searchDocs(t) {
+			rank, ok := searchRank(d, terms)
+			if !ok {
+				continue
+			}
+			results = append(results, models.SearchResult{Type: d.typ, ID: d.id, Title: d.title, Snippet: highlight(d.body, terms), Rank: rank})
+		}
+	}
+	r.m.mu.RUnlock()
+
+	slices.SortFunc(results, func(a, b models.SearchResult) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	total := len(results)
+	results = results[min(offset, total):]
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, total, nil
+}
+
+// 每个词都命中时返回相关度
+func searchRank(d memorySearchDoc, terms []string) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+	title, body := strings.ToLower(d.title), strings.ToLower(d.body)
+	var rank float64
+	for _, t := range terms {
+		inTitle, inBody := strings.Contains(title, t), strings.Contains(body, t)
+		if !inTitle && !inBody {
+			return 0, false
+		}
+		if inTitle {
+			rank += 2
+		}
+		if inBody {
+			rank++
+		}
+	}
+	return rank, true
+}
+
+// 转义正文并用 <mark> 标记命中的词；转小写后长度变化的正文（少数非 ASCII 字符）只转义不标记
+func highlight(body string, terms []string) string {
+	lower := strings.ToLower(body)
+	if len(lower) != len(body) {
+		return html.EscapeString(body)
+	}
+	marked := make([]bool, len(body))
+	for _, t := range terms {
+		for i := 0; i+len(t) <= len(lower); {
+			j := strings.Index(lower[i:], t)
+			if j < 0 {
+				break
+			}
+			for k := i + j; k < i+j+len(t); k++ {
+				marked[k] = true
+			}
+			i += j + len(t)
+		}
+	}
+	var sb strings.Builder
+	for i := 0; i < len(body); {
+		j := i
+		for j < len(body) && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			sb.WriteString("<mark>" + html.EscapeString(body[i:j]) + "</mark>")
+		} else {
+			sb.WriteString(html.EscapeString(body[i:j]))
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+type memoryCrawlJobRepository struct{ m *memoryStore }
+
+func cloneCrawlJob(job models.CrawlJob) models.CrawlJob {
+	job.Softwares = slices.Clone(job.Softwares)
+	for i := range job.Softwares {
+		sp := &job.Softwares[i]
+		sp.Errors = slices.Clone(sp.Errors)
+		if sp.Checkpoint != nil {
+			cp := *sp.Checkpoint
+			sp.Checkpoint = &cp
+		}
+	}
+	return job
+}
+
+func leaseUntil(lease time.Duration) *time.Time {
+	t := time.Now().Add(lease)
+	return &t
+}
+
+func leaseExpired(job models.CrawlJob) bool {
+	return job.LeaseExpiresAt == nil || job.LeaseExpiresAt.Before(time.Now())
+}
+
+func (r *memoryCrawlJobRepository) Create(ctx context.Context, job *models.CrawlJob, lease time.Duration) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.m.nextCrawlJobID++
+	job.ID = r.m.nextCrawlJobID
+	job.CreatedAt = time.Now()
+	job.LeaseExpiresAt = leaseUntil(lease)
+	r.m.crawlJobs[job.ID] = cloneCrawlJob(*job)
+	return nil
+}
+
+// 与 PostgreSQL 实现一致：只修改状态、进度、计数和时间，离开 queued/running 时释放租约
+func (r *memoryCrawlJobRepository) Update(ctx context.Context, job *models.CrawlJob) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	stored, ok := r.m.crawlJobs[job.ID]
+	if !ok || stored.InstanceID != job.InstanceID || stored.Finished() {
+		return ErrCrawlJobLeaseLost
+	}
+	stored.State = job.State
+	stored.Softwares = job.Softwares
+	stored.CrawlStats = job.CrawlStats
+	stored.Error = job.Error
+	stored.StartedAt = job.StartedAt
+	stored.FinishedAt = job.FinishedAt
+	if job.State != models.CrawlJobQueued && job.State != models.CrawlJobRunning {
+		stored.LeaseExpiresAt = nil
+	}
+	r.m.crawlJobs[job.ID] = cloneCrawlJob(stored)
+	return nil
+}
+
+func (r *memoryCrawlJobRepository) GetByID(ctx context.Context, id int64) (*models.CrawlJob, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	job, ok := r.m.crawlJobs[id]
+	if !ok {
+		return nil, ErrCrawlJobNotFound
+	}
+	job = cloneCrawlJob(job)
+	return &job, nil
+}
+
+func (r *memoryCrawlJobRepository) RequestCancel(ctx context.Context, id int64) (*models.CrawlJob, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	job, ok := r.m.crawlJobs[id]
+	if !ok {
+		return nil, ErrCrawlJobNotFound
+	}
+	if job.Finished() {
+		job = cloneCrawlJob(job)
+		return &job, ErrCrawlJobFinished
+	}
+	job.CancelRequested = true
+	if job.State == models.CrawlJobQueued || job.State == models.CrawlJobInterrupted {
+		now := time.Now()
+		job.State = models.CrawlJobCancelled
+		job.FinishedAt = &now
+		job.LeaseExpiresAt = nil
+	}
+	r.m.crawlJobs[id] = job
+	job = cloneCrawlJob(job)
+	return &job, nil
+}
+
+func (r *memoryCrawlJobRepository) CancelRequested(ctx context.Context, id int64) (bool, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	job, ok := r.m.crawlJobs[id]
+	if !ok {
+		return false, ErrCrawlJobNotFound
+	}
+	return job.CancelRequested, nil
+}
+
+func (r *memoryCrawlJobRepository) RenewLeases(ctx context.Context, instanceID string, lease time.Duration) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var n int64
+	for id, job := range r.m.crawlJobs {
+		if job.InstanceID == instanceID && (job.State == models.CrawlJobQueued || job.State == models.CrawlJobRunning) {
+			job.LeaseExpiresAt = leaseUntil(lease)
+			r.m.crawlJobs[id] = job
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memoryCrawlJobRepository) FailExpired(ctx context.Context) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var n int64
+	for id, job := range r.m.crawlJobs {
+		if (job.State != models.CrawlJobQueued && job.State != models.CrawlJobRunning) || !leaseExpired(job) {
+			continue
+		}
+		now := time.Now()
+		if job.CancelRequested {
+			job.State = models.CrawlJobCancelled
+		} else {
+			job.State = models.CrawlJobFailed
+			job.Error = "unavailable: the instance running this job stopped unexpectedly"
+		}
+		job.FinishedAt = &now
+		job.LeaseExpiresAt = nil
+		r.m.crawlJobs[id] = job
+		n++
+	}
+	return n, nil
+}
+
+func (r *memoryCrawlJobRepository) ClaimInterrupted(ctx context.Context, instanceID string, lease time.Duration, limit int) ([]*models.CrawlJob, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	ids := slices.Sorted(maps.Keys(r.m.crawlJobs))
+	var jobs []*models.CrawlJob
+	for _, id := range ids {
+		if len(jobs) == limit {
+			break
+		}
+		job := r.m.crawlJobs[id]
+		if job.State != models.CrawlJobInterrupted || !leaseExpired(job) {
+			continue
+		}
+		job.State = models.CrawlJobQueued
+		job.InstanceID = instanceID
+		job.LeaseExpiresAt = leaseUntil(lease)
+		r.m.crawlJobs[id] = job
+		claimed := cloneCrawlJob(job)
+		jobs = append(jobs, &claimed)
+	}
+	return jobs, nil
+}
+
+type memoryCrawlRunRepository struct{ m *memoryStore }
+
+func (r *memoryCrawlRunRepository) Create(ctx context.Context, run *models.CrawlRun) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.m.nextCrawlRunID++
+	run.ID = r.m.nextCrawlRunID
+	run.StartedAt = time.Now()
+	r.m.crawlRuns[run.ID] = *run
+	return nil
+}
+
+func (r *memoryCrawlRunRepository) Update(ctx context.Context, run *models.CrawlRun) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	stored, ok := r.m.crawlRuns[run.ID]
+	if !ok {
+		return nil
+	}
+	stored.State, stored.JobID, stored.Error, stored.FinishedAt = run.State, run.JobID, run.Error, run.FinishedAt
+	r.m.crawlRuns[run.ID] = stored
+	return nil
+}
+
+func (r *memoryCrawlRunRepository) Query(ctx context.Context, page PageRequest) (*Page[models.CrawlRun], error) {
+	r.m.mu.RLock()
+	runs := slices.Collect(maps.Values(r.m.crawlRuns))
+	r.m.mu.RUnlock()
+	return paginate(runs, page, crawlRunSort, crawlRunSortKey)
+}
+
+func (r *memoryCrawlRunRepository) GetLatest(ctx context.Context, state string) (*models.CrawlRun, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	var latest *models.CrawlRun
+	for _, run := range r.m.crawlRuns {
+		if (state == "" || run.State == state) && (latest == nil || run.ID > latest.ID) {
+			latest = &run
+		}
+	}
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (r *memoryCrawlRunRepository) FailUnfinished(ctx context.Context) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var n int64
+	for id, run := range r.m.crawlRuns {
+		if run.FinishedAt != nil {
+			continue
+		}
+		now := time.Now()
+		run.State = models.CrawlJobFailed
+		run.Error = "interrupted by server restart"
+		run.FinishedAt = &now
+		r.m.crawlRuns[id] = run
+		n++
+	}
+	return n, nil
+}
+
+type memoryCrawlStateRepository struct{ m *memoryStore }
+
+func (r *memoryCrawlStateRepository) Get(ctx context.Context, softwareName string) (*models.SoftwareCrawlState, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	s, ok := r.m.softwareByName(softwareName)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	state, ok := r.m.crawlStates[s.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &state, nil
+}
+
+func (r *memoryCrawlStateRepository) Save(ctx context.Context, softwareName string, state *models.SoftwareCrawlState) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	s, ok := r.m.softwareByName(softwareName)
+	if !ok {
+		return nil
+	}
+	stored := *state
+	stored.SoftwareID = s.ID
+	r.m.crawlStates[s.ID] = stored
+	return nil
+}
+
+// 进程内的锁，内存存储只在单个进程中使用
+type memoryLocker struct{ m *memoryStore }
+
+func (r *memoryLocker) TryLock(ctx context.Context, key int64) (release func(), ok bool, err error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if r.m.locks[key] {
+		return nil, false, nil
+	}
+	r.m.locks[key] = true
+	release = func() {
+		r.m.mu.Lock()
+		defer r.m.mu.Unlock()
+		delete(r.m.locks, key)
+	}
+	return release, true, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"hpc-site/internal/apperr"
+)
+
+var (
+	ErrInvalidSort   = apperr.New(apperr.Validation, "invalid sort column")
+	ErrInvalidCursor = apperr.New(apperr.Validation, "invalid cursor")
+)
+
+// 列表分页参数，Limit 为 0 表示不分页（仅供内部使用）
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string // keyset 游标，设置后忽略 Offset
+	Sort   string // 排序列，为空时使用默认列
+	Desc   bool
+}
+
+// 列表接口统一的响应结构
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// 可排序列：SQL 表达式和游标值的类型转换。表达式的值不能为 NULL，否则游标的行比较会漏掉这些行，可空列需用 COALESCE
+type sortColumn struct {
+	expr string
+	cast string
+}
+
+// 每张表的排序白名单
+type sortSpec struct {
+	columns     map[string]sortColumn
+	defaultSort string
+	defaultDesc bool
+	id          sortColumn // keyset 的次级排序键
+}
+
+// 游标内容：排序列、最后一行的排序值和 id
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func (p *PageRequest) resolve(spec sortSpec) (sortColumn, error) {
+	if p.Sort == "" {
+		p.Sort = spec.defaultSort
+		p.Desc = spec.defaultDesc
+	}
+	col, ok := spec.columns[p.Sort]
+	if !ok {
+		return sortColumn{}, ErrInvalidSort.WithDetails(map[string]string{"sort": p.Sort})
+	}
+	return col, nil
+}
+
+// 在以 "WHERE ..." 结尾的查询后追加游标条件、排序和 LIMIT/OFFSET
+func (p *PageRequest) apply(query string, args []any, spec sortSpec) (string, []any, error) {
+	col, err := p.resolve(spec)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, cmp := "ASC", ">"
+	if p.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if p.Cursor != "" {
+		cur, err := decodeCursor(p.Cursor)
+		if err != nil || cur.Sort != p.Sort || cur.Desc != p.Desc {
+			return "", nil, ErrInvalidCursor
+		}
+		query += fmt.Sprintf(" AND (%s, %s) %s ($%d%s, $%d%s)",
+			col.expr, spec.id.expr, cmp, len(args)+1, col.cast, len(args)+2, spec.id.cast)
+		args = append(args, cur.Value, cur.ID)
+		p.Offset = 0
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s, %s %s", col.expr, dir, spec.id.expr, dir)
+
+	if p.Limit > 0 {
+		// 多取一条用于判断是否还有下一页
+		query += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, p.Limit+1)
+	}
+	if p.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", len(args)+1)
+		args = append(args, p.Offset)
+	}
+	return query, args, nil
+}
+
+// 截断多取的一行并生成下一页游标，key 返回某行的排序值和 id
+func newPage[T any](p PageRequest, items []T, total int, key func(T, string) (string, string)) *Page[T] {
+	page := &Page[T]{Items: items, Total: total, Limit: p.Limit, Offset: p.Offset}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if p.Limit > 0 && len(items) > p.Limit {
+		page.Items = items[:p.Limit]
+		value, id := key(page.Items[p.Limit-1], p.Sort)
+		page.NextCursor = encodeCursor(pageCursor{Sort: p.Sort, Desc: p.Desc, Value: value, ID: id})
+	}
+	return page
+}
+
+func countRows(ctx context.Context, db *sql.DB, query string, args []any) (int, error) {
+	var total int
+	err := db.QueryRowContext(ctx, query, args...).Scan(&total)
+	return total, err
+}
+
+func encodeCursor(c pageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
@@ -3,20 +3,172 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"github.com/lib/pq"
 	"hpc-site/internal/models"
-	"hpc-site/pkg"
-	"log"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
 
-// 获取所有论文
-func GetAllPapers(ctx context.Context) ([]models.Paper, error) {
-	rows, err := pkg.DB.QueryContext(ctx,
-		`SELECT id, title, authors, abstract, url, software_names, created_at 
-		 FROM paper ORDER BY id`)
+// 基于 PostgreSQL 的 PaperRepository
+type PostgresPaperRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresPaperRepository(db *sql.DB, queryTimeout time.Duration) *PostgresPaperRepository {
+	return &PostgresPaperRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// 查询论文时统一使用的列（paper 表别名为 p），与 scanPaper 的顺序一致；
+// 关联的软件名和置信度从 paper_software 聚合
+const paperColumns = `p.id, p.title, p.authors, p.abstract, p.url, COALESCE(p.pdf, ''),
+	ARRAY(SELECT s.name FROM paper_software ps JOIN software s ON s.id = ps.software_id
+	      WHERE ps.paper_id = p.id ORDER BY ps.created_at, s.name), p.created_at,
+	COALESCE(p.published_time, ''), COALESCE(p.withdrawn, FALSE), COALESCE(p.updated_time, ''),
+	COALESCE(p.latest_version, 0), p.categories, COALESCE(p.doi, ''), COALESCE(p.journal_ref, ''),
+	COALESCE(p.source, 'arxiv'), COALESCE(p.external_ids, '{}'::jsonb),
+	COALESCE((SELECT jsonb_object_agg(s.name, ps.confidence) FROM paper_software ps JOIN software s ON s.id = ps.software_id
+	          WHERE ps.paper_id = p.id AND ps.confidence IS NOT NULL), '{}'::jsonb)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPaper(row rowScanner) (models.Paper, error) {
+	var p models.Paper
+	var externalIDs, confidence []byte
+	err := row.Scan(&p.ID, &p.Title, pq.Array(&p.Authors), &p.Abstract, &p.URL, &p.Pdf, pq.Array(&p.SoftwareNames), &p.CreatedAt,
+		&p.PublishedTime, &p.Withdrawn, &p.UpdatedTime,
+		&p.Version, pq.Array(&p.Categories), &p.DOI, &p.JournalRef,
+		&p.Source, &externalIDs, &confidence)
+	if err != nil {
+		return p, err
+	}
+	json.Unmarshal(externalIDs, &p.ExternalIDs)
+	json.Unmarshal(confidence, &p.SoftwareConfidence)
+	return p, nil
+}
+
+// 论文列表可排序的列
+var paperSort = sortSpec{
+	columns: map[string]sortColumn{
+		"id":         {expr: "id", cast: "::text"},
+		"title":      {expr: "title", cast: "::text"},
+		"created_at": {expr: "created_at", cast: "::timestamp"},
+	},
+	defaultSort: "id",
+	id:          sortColumn{expr: "id", cast: "::text"},
+}
+
+func paperSortKey(p models.Paper, sort string) (string, string) {
+	switch sort {
+	case "title":
+		return p.Title, p.ID
+	case "created_at":
+		return p.CreatedAt.Format(time.RFC3339Nano), p.ID
+	}
+	return p.ID, p.ID
+}
+
+// 论文过滤条件，零值表示不过滤
+type PaperFilter struct {
+	Software  string     // 关联了该软件（按软件名，不区分大小写）
+	Author    string     // 作者名模糊匹配
+	From      *time.Time // 发表日期下限（含）
+	To        *time.Time // 发表日期上限（含）
+	Search    string     // 标题和摘要模糊匹配
+	Withdrawn *bool
+}
+
+var (
+	isoDateRe  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+	httpDateRe = regexp.MustCompile(`^[A-Za-z]{3}, \d{1,2} [A-Za-z]{3} \d{4}`)
+)
+
+// published_time 是抓取时的原始文本（如 "Wed, 29 May 2024 15:33:28 UTC" 或 "2024-05-29T15:33:28Z"），
+// 写入时解析出日期存到 published_date 列，供日期过滤使用
+func publishedDate(published string) (time.Time, bool) {
+	if s := isoDateRe.FindString(published); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		return t, err == nil
+	}
+	if s := httpDateRe.FindString(published); s != "" {
+		t, err := time.Parse("2 Jan 2006", s[5:])
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// published_date 列的值，无法解析时为 NULL
+func publishedDateArg(published string) any {
+	if t, ok := publishedDate(published); ok {
+		return t.Format("2006-01-02")
+	}
+	return nil
+}
+
+// 已发布的论文：至少关联了一个软件，只有待审核关联的论文不对外展示
+const paperPublished = `EXISTS (SELECT 1 FROM paper_software ps WHERE ps.paper_id = p.id)`
+
+// 论文查询（支持过滤和分页）
+func (r *PostgresPaperRepository) Query(ctx context.Context, filter PaperFilter, page PageRequest) (*Page[models.Paper], error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	where := `
+		FROM paper p
+		WHERE ` + paperPublished + `
+	`
+	var args []interface{}
+	argID := 1
+
+	if filter.Software != "" {
+		where += fmt.Sprintf(` AND EXISTS (SELECT 1 FROM paper_software ps JOIN software s ON s.id = ps.software_id
+			WHERE ps.paper_id = p.id AND LOWER(s.name) = LOWER($%d))`, argID)
+		args = append(args, filter.Software)
+		argID++
+	}
+	if filter.Author != "" {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM unnest(authors) a WHERE a ILIKE $%d)", argID)
+		args = append(args, "%"+filter.Author+"%")
+		argID++
+	}
+	if filter.From != nil {
+		where += fmt.Sprintf(" AND p.published_date >= $%d::date", argID)
+		args = append(args, filter.From.Format("2006-01-02"))
+		argID++
+	}
+	if filter.To != nil {
+		where += fmt.Sprintf(" AND p.published_date <= $%d::date", argID)
+		args = append(args, filter.To.Format("2006-01-02"))
+		argID++
+	}
+	if filter.Search != "" {
+		where += fmt.Sprintf(" AND (title ILIKE $%d OR abstract ILIKE $%d)", argID, argID)
+		args = append(args, "%"+filter.Search+"%")
+		argID++
+	}
+	if filter.Withdrawn != nil {
+		where += fmt.Sprintf(" AND COALESCE(withdrawn, FALSE) = $%d", argID)
+		args = append(args, *filter.Withdrawn)
+		argID++
+	}
+
+	total, err := countRows(ctx, r.db, "SELECT COUNT(*)"+where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := page.apply("SELECT "+paperColumns+where, args, paperSort)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -24,139 +176,359 @@ func GetAllPapers(ctx context.Context) ([]models.Paper, error) {
 
 	var papers []models.Paper
 	for rows.Next() {
-		var p models.Paper
-		err := rows.Scan(&p.ID, &p.Title, pq.Array(&p.Authors), &p.Abstract, &p.URL, pq.Array(&p.SoftwareNames), &p.CreatedAt)
+		p, err := scanPaper(rows)
 		if err != nil {
 			return nil, err
 		}
 		papers = append(papers, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return papers, rows.Err()
+	return newPage(page, papers, total, paperSortKey), nil
+}
+
+// 已存在的论文中，来源没有给出（为空）的元数据保留原值：部分来源或接口不返回摘要、作者、PDF 或发表时间
+const (
+	upsertAuthors       = `COALESCE(NULLIF(EXCLUDED.authors, '{}'), paper.authors)`
+	upsertAbstract      = `COALESCE(NULLIF(EXCLUDED.abstract, ''), paper.abstract)`
+	upsertPdf           = `COALESCE(NULLIF(EXCLUDED.pdf, ''), paper.pdf)`
+	upsertPublishedTime = `COALESCE(NULLIF(EXCLUDED.published_time, ''), paper.published_time)`
+	// 与 published_time 一起保留或更新
+	upsertPublishedDate = `CASE WHEN NULLIF(EXCLUDED.published_time, '') IS NULL THEN paper.published_date ELSE EXCLUDED.published_date END`
+)
+
+// 插入论文；已存在时合并 external_ids，DOI、期刊信息、摘要、作者、PDF 和发表时间为空时保留原值，
+// 其余元数据以新值为准，元数据没有变化时不改写该行。created_at 保持首次插入的时间
+const upsertPaperSQL = `
+	INSERT INTO paper (id, title, authors, abstract, url, pdf, published_time, withdrawn,
+	                   updated_time, latest_version, categories, doi, journal_ref, source, external_ids, published_date)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	ON CONFLICT (id) DO UPDATE SET
+		title = EXCLUDED.title, authors = ` + upsertAuthors + `, abstract = ` + upsertAbstract + `,
+		url = EXCLUDED.url, pdf = ` + upsertPdf + `, published_time = ` + upsertPublishedTime + `,
+		published_date = ` + upsertPublishedDate + `, withdrawn = EXCLUDED.withdrawn, updated_time = EXCLUDED.updated_time,
+		latest_version = EXCLUDED.latest_version, categories = EXCLUDED.categories,
+		doi = COALESCE(NULLIF(EXCLUDED.doi, ''), paper.doi),
+		journal_ref = COALESCE(NULLIF(EXCLUDED.journal_ref, ''), paper.journal_ref),
+		source = EXCLUDED.source,
+		external_ids = paper.external_ids || EXCLUDED.external_ids
+	WHERE (paper.title, paper.authors, paper.abstract, paper.url, paper.pdf, paper.published_time, paper.withdrawn,
+	       paper.updated_time, paper.latest_version, paper.categories, paper.source)
+	      IS DISTINCT FROM
+	      (EXCLUDED.title, ` + upsertAuthors + `, ` + upsertAbstract + `, EXCLUDED.url, ` + upsertPdf + `, ` + upsertPublishedTime + `,
+	       EXCLUDED.withdrawn, EXCLUDED.updated_time, EXCLUDED.latest_version, EXCLUDED.categories, EXCLUDED.source)
+	   OR (EXCLUDED.doi <> '' AND paper.doi IS DISTINCT FROM EXCLUDED.doi)
+	   OR (EXCLUDED.journal_ref <> '' AND paper.journal_ref IS DISTINCT FROM EXCLUDED.journal_ref)
+	   OR NOT paper.external_ids @> EXCLUDED.external_ids`
+
+// 插入或更新论文，并按 SoftwareNames 补充关联（置信度取自 SoftwareConfidence，已有的关联保留），
+// 整个过程在一个事务中完成，并发抓取同一篇论文时不会丢失任何一方的关联
+func (r *PostgresPaperRepository) Upsert(ctx context.Context, paper models.Paper) error {
+	failed, err := r.UpsertBatch(ctx, []models.Paper{paper}, nil)
+	if err != nil {
+		return err
+	}
+	return failed[paper.ID]
+}
+
+// 在一个事务中批量插入或更新论文及其关联，语义同 Upsert，reviews 中的审核记录在同一事务中放入审核队列。
+// 每篇论文使用一个 savepoint，单篇失败只回滚这一篇（连同其关联和审核记录），通过 failed 返回；
+// 返回 err 时整批都没有写入
+func (r *PostgresPaperRepository) UpsertBatch(ctx context.Context, papers []models.Paper, reviews []models.PaperReview) (failed map[string]error, err error) {
+	if len(papers) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// 按 ID 顺序加行锁，避免两个批次互相等待对方持有的行
+	papers = slices.Clone(papers)
+	slices.SortFunc(papers, func(a, b models.Paper) int { return strings.Compare(a.ID, b.ID) })
+	reviewsByPaper := make(map[string][]models.PaperReview)
+	for _, rv := range reviews {
+		reviewsByPaper[rv.PaperID] = append(reviewsByPaper[rv.PaperID], rv)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	upsert, err := tx.PrepareContext(ctx, upsertPaperSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer upsert.Close()
+	link, err := tx.PrepareContext(ctx, linkPaperSoftwareByNameSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer link.Close()
+	queue, err := tx.PrepareContext(ctx, queuePaperReviewSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer queue.Close()
+
+	failed = make(map[string]error)
+	for _, paper := range papers {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT paper_upsert"); err != nil {
+			return nil, err
+		}
+		if err := upsertPaperTx(ctx, upsert, link, queue, paper, reviewsByPaper[paper.ID]); err != nil {
+			// 回滚失败说明事务已不可用（如超时、连接断开），整批放弃
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT paper_upsert"); rbErr != nil {
+				return nil, fmt.Errorf("%w (rollback to savepoint: %v)", err, rbErr)
+			}
+			failed[paper.ID] = err
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT paper_upsert"); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return failed, nil
 }
 
-// 第一种情况paper不存在 insert
-func InsertNewPaper(paper models.Paper) error {
-	log.Printf("Inserting new paper %s with software", paper.ID)
-	_, err := pkg.DB.Exec(`INSERT INTO paper(id, title, authors, abstract, url, pdf, software_names, published_time,created_at)
-            VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+// 在事务中写入单篇论文、关联和审核记录
+func upsertPaperTx(ctx context.Context, upsert, link, queue *sql.Stmt, paper models.Paper, reviews []models.PaperReview) error {
+	source, externalIDs, err := paperSourceColumns(paper)
+	if err != nil {
+		return err
+	}
+	_, err = upsert.ExecContext(ctx,
 		paper.ID,
 		paper.Title,
 		pq.Array(paper.Authors),
 		paper.Abstract,
 		paper.URL,
 		paper.Pdf,
-		pq.Array(paper.SoftwareNames), // 插入软件名数组
 		paper.PublishedTime,
-		time.Now(),
+		paper.Withdrawn,
+		paper.UpdatedTime,
+		paper.Version,
+		pq.Array(paper.Categories),
+		paper.DOI,
+		paper.JournalRef,
+		source,
+		externalIDs,
+		publishedDateArg(paper.PublishedTime),
 	)
 	if err != nil {
-		return err
+		return fmt.Errorf("upsert paper %s: %w", paper.ID, err)
+	}
+	for _, name := range paper.SoftwareNames {
+		var confidence *float64
+		if c, ok := paper.SoftwareConfidence[name]; ok {
+			confidence = &c
+		}
+		if _, err := link.ExecContext(ctx, paper.ID, name, LinkSourceCrawl, confidence); err != nil {
+			return fmt.Errorf("link paper %s to %s: %w", paper.ID, name, err)
+		}
+	}
+	for _, rv := range reviews {
+		if _, err := queue.ExecContext(ctx, rv.PaperID, rv.SoftwareID, rv.Confidence, pq.Array(nonNil(rv.Reasons))); err != nil {
+			return fmt.Errorf("queue review for paper %s: %w", paper.ID, err)
+		}
 	}
 	return nil
 }
 
-// paper存在但是software不存在
-func UpdatePaperSoftware(paperID string, updatedSoftwareNames []string) error {
-	sql := `UPDATE paper SET software_names = $1 WHERE id = $2`
-	_, err := pkg.DB.Exec(sql, pq.Array(updatedSoftwareNames), paperID)
+// 刷新论文元数据（不修改关联的软件），论文不存在时返回 ErrPaperNotFound
+func (r *PostgresPaperRepository) UpdateMetadata(ctx context.Context, paper models.Paper) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	source, externalIDs, err := paperSourceColumns(paper)
 	if err != nil {
 		return err
 	}
-	return nil
-}
-
-// 第三种情况
-func InsertOrUpdatePaper(paper models.Paper) error {
-	var soft []string
-	err := pkg.DB.QueryRow(`SELECT software_names FROM paper WHERE id = $1`, paper.ID).Scan(pq.Array(&soft))
-	if err == sql.ErrNoRows {
-		//not existing insert
-		return InsertNewPaper(paper)
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE paper
+		SET title = $1, authors = $2, abstract = $3, url = $4, pdf = $5, published_time = $6, withdrawn = $7,
+		    updated_time = $8, latest_version = $9, categories = $10, doi = $11, journal_ref = $12,
+		    source = $13, external_ids = $14, published_date = $15
+		WHERE id = $16`,
+		paper.Title,
+		pq.Array(paper.Authors),
+		paper.Abstract,
+		paper.URL,
+		paper.Pdf,
+		paper.PublishedTime,
+		paper.Withdrawn,
+		paper.UpdatedTime,
+		paper.Version,
+		pq.Array(paper.Categories),
+		paper.DOI,
+		paper.JournalRef,
+		source,
+		externalIDs,
+		publishedDateArg(paper.PublishedTime),
+		paper.ID,
+	)
+	if err != nil {
+		return err
 	}
+	n, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if n == 0 {
+		return ErrPaperNotFound
+	}
+	return nil
+}
 
-	//existing
-	existing := []string(soft)
-	merged := MergeUnique(existing, paper.SoftwareNames)
-	if len(merged) == len(existing) {
-		log.Printf("Paper %s already contains software(s): %v — skip", paper.ID, paper.SoftwareNames)
-		return nil
+// 来源默认为 arxiv，external_ids 序列化为 JSONB
+func paperSourceColumns(paper models.Paper) (string, []byte, error) {
+	source := paper.Source
+	if source == "" {
+		source = "arxiv"
 	}
+	ids := paper.ExternalIDs
+	if ids == nil {
+		ids = map[string]string{}
+	}
+	b, err := json.Marshal(ids)
+	return source, b, err
+}
 
-	// 否则更新合并后的列表
-	return UpdatePaperSoftware(paper.ID, merged)
+// 按 ID 获取单篇论文
+func (r *PostgresPaperRepository) GetByID(ctx context.Context, id string) (*models.Paper, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	p, err := scanPaper(r.db.QueryRowContext(ctx, `SELECT `+paperColumns+` FROM paper p WHERE p.id = $1`, id))
+	if err != nil {
+		return nil, notFoundAs(err, ErrPaperNotFound)
+	}
+	return &p, nil
 }
 
-func parseSoftwareNames(raw string) []string {
-	if strings.TrimSpace(raw) == "" {
-		return []string{}
+// 查询某个软件相关的论文
+func (r *PostgresPaperRepository) GetBySoftwareID(ctx context.Context, id int) ([]models.Paper, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+    SELECT ` + paperColumns + `
+    FROM paper p
+    JOIN paper_software link ON link.paper_id = p.id
+    WHERE link.software_id = $1
+    ORDER BY link.created_at DESC, p.id
+`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
 	}
-	// 支持 ; 或 , 或 空格分割的情况
-	parts := regexp.MustCompile(`[;,]`).Split(raw, -1)
-	out := make([]string, 0, len(parts))
-	for _, p := range parts {
-		s := strings.TrimSpace(p)
-		if s != "" {
-			out = append(out, s)
+	defer rows.Close()
+
+	var papers []models.Paper
+	for rows.Next() {
+		p, err := scanPaper(rows)
+		if err != nil {
+			return nil, err
 		}
+		papers = append(papers, p)
 	}
-	return out
+	return papers, rows.Err()
 }
 
-func MergeUnique(a, b []string) []string {
-	seen := map[string]bool{}
-	out := make([]string, 0, len(a)+len(b))
+// 论文—软件关联的来源
+const (
+	LinkSourceCrawl  = "crawl"  // 抓取时置信度达到阈值自动关联
+	LinkSourceReview = "review" // 人工审核通过
+	LinkSourceManual = "manual" // 直接指定
+)
 
-	// 保留 a 的原始顺序/大小写
-	for _, s := range a {
-		if s == "" {
-			continue
-		}
-		if !seen[s] {
-			seen[s] = true
-			out = append(out, s)
-		}
-	}
-	// 再把 b 的补充加入
-	for _, s := range b {
-		if s == "" {
-			continue
-		}
-		if !seen[s] {
-			seen[s] = true
-			out = append(out, s)
-		}
+// 关联论文 $1 和软件 $2（ID），来源 $3，置信度 $4；已关联时只更新置信度
+const linkPaperSoftwareSQL = `
+	INSERT INTO paper_software (paper_id, software_id, source, confidence)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (paper_id, software_id) DO UPDATE SET confidence = COALESCE(EXCLUDED.confidence, paper_software.confidence)`
+
+// 同上，软件按名称 $2 查找（不区分大小写），软件不存在时什么也不做
+const linkPaperSoftwareByNameSQL = `
+	INSERT INTO paper_software (paper_id, software_id, source, confidence)
+	SELECT $1, id, $3, $4 FROM software WHERE LOWER(name) = LOWER($2)
+	ON CONFLICT (paper_id, software_id) DO UPDATE SET confidence = COALESCE(EXCLUDED.confidence, paper_software.confidence)`
+
+// 把软件关联到已存在的论文并记录置信度；论文或软件不存在时返回 ErrPaperOrSoftwareNotFound
+func (r *PostgresPaperRepository) LinkSoftware(ctx context.Context, paperID string, softwareID int, source string, confidence float64) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, linkPaperSoftwareSQL, paperID, softwareID, source, confidence)
+	if isForeignKeyViolation(err) {
+		return ErrPaperOrSoftwareNotFound
 	}
-	return out
+	return err
+}
+
+// 已入库论文的 ID、用于相关度评分的标题摘要、已关联的软件和已进入审核队列的软件（均为软件 ID）
+type ExistingPaper struct {
+	ID       string
+	Title    string
+	Abstract string
+	Linked   []int64
+	Reviewed []int64
 }
 
-func CheckPaperExists(paperID string) (bool, []string, error) {
-	var softwares pq.StringArray
-	err := pkg.DB.QueryRow(`SELECT software_names FROM paper WHERE id = $1`, paperID).
-		Scan(pq.Array(&softwares))
+// 每条 ANY($1) 查询携带的最大 ID 数
+const existsBatchSize = 1000
+
+// 批量检查论文是否存在，返回 ID → 已入库论文
+func (r *PostgresPaperRepository) GetExisting(ctx context.Context, ids []string) (map[string]ExistingPaper, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+	return r.queryExisting(ctx, `SELECT p.id, `+existingPaperColumns+` FROM paper p WHERE p.id = ANY($1)`, ids)
+}
 
-	if err == sql.ErrNoRows {
-		return false, nil, nil
+// 按 DOI 批量查找已入库论文（不区分大小写），返回小写 DOI → 已入库论文
+func (r *PostgresPaperRepository) GetExistingByDOI(ctx context.Context, dois []string) (map[string]ExistingPaper, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	lower := make([]string, 0, len(dois))
+	for _, d := range dois {
+		lower = append(lower, strings.ToLower(d))
 	}
+	return r.queryExisting(ctx, `SELECT LOWER(p.doi), `+existingPaperColumns+` FROM paper p WHERE LOWER(p.doi) = ANY($1)`, lower)
+}
 
-	if err != nil {
-		//尝试把字符串手动解析成 []string
-		var raw string
-		rawErr := pkg.DB.QueryRow(`SELECT software_names::text FROM paper WHERE id = $1`, paperID).
-			Scan(&raw)
-		if rawErr == nil && raw != "" {
-			clean := strings.Trim(raw, "{}\" ")
-			if clean == "" {
-				return true, []string{}, nil
+const existingPaperColumns = `p.id, p.title, COALESCE(p.abstract, ''),
+	ARRAY(SELECT ps.software_id FROM paper_software ps WHERE ps.paper_id = p.id),
+	ARRAY(SELECT r.software_id FROM paper_review r WHERE r.paper_id = p.id)`
+
+func (r *PostgresPaperRepository) queryExisting(ctx context.Context, query string, keys []string) (map[string]ExistingPaper, error) {
+	result := make(map[string]ExistingPaper, len(keys))
+	for start := 0; start < len(keys); start += existsBatchSize {
+		end := min(start+existsBatchSize, len(keys))
+		rows, err := r.db.QueryContext(ctx, query, pq.Array(keys[start:end]))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key string
+			var p ExistingPaper
+			if err := rows.Scan(&key, &p.ID, &p.Title, &p.Abstract, pq.Array(&p.Linked), pq.Array(&p.Reviewed)); err != nil {
+				rows.Close()
+				return nil, err
 			}
-			return true, strings.Split(clean, ","), nil
+			result[key] = p
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
 		}
-		log.Printf("检查论文 %s 是否存在时出错: %v", paperID, err)
-		return false, nil, err
 	}
-
-	return true, []string(softwares), nil
+	return result, nil
 }
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+// 软件存储。不存在时返回 ErrSoftwareNotFound，重名时返回 ErrSoftwareNameConflict，
+// 删除还有 benchmark 的软件时返回 ErrSoftwareHasBenchmarks
+type SoftwareRepository interface {
+	Query(ctx context.Context, filter SoftwareFilter, page PageRequest) (*Page[models.Software], error)
+	GetByID(ctx context.Context, id int) (*models.Software, error)
+	GetByName(ctx context.Context, name string) (*models.Software, error)
+	Create(ctx context.Context, s *models.Software) error
+	Update(ctx context.Context, s *models.Software) error
+	Delete(ctx context.Context, id int) error
+}
+
+// 论文及论文—软件关联的存储。不存在时返回 ErrPaperNotFound
+type PaperRepository interface {
+	// 已发布（至少关联了一个软件）的论文
+	Query(ctx context.Context, filter PaperFilter, page PageRequest) (*Page[models.Paper], error)
+	GetByID(ctx context.Context, id string) (*models.Paper, error)
+	GetBySoftwareID(ctx context.Context, softwareID int) ([]models.Paper, error)
+	// 插入或更新论文，并按 SoftwareNames 补充关联软件（置信度取自 SoftwareConfidence），在一个事务中完成
+	Upsert(ctx context.Context, paper models.Paper) error
+	// 批量版本的 Upsert，reviews 中的审核记录一并写入。整批在一个事务中完成，单篇失败时只跳过这一篇，
+	// 通过 failed（论文 ID → 错误）返回；返回 err 时整批都没有写入
+	UpsertBatch(ctx context.Context, papers []models.Paper, reviews []models.PaperReview) (failed map[string]error, err error)
+	// 刷新论文元数据，不修改关联的软件
+	UpdateMetadata(ctx context.Context, paper models.Paper) error
+	LinkSoftware(ctx context.Context, paperID string, softwareID int, source string, confidence float64) error
+	// 批量检查论文是否存在，返回 ID → 已入库论文
+	GetExisting(ctx context.Context, ids []string) (map[string]ExistingPaper, error)
+	// 按 DOI 批量查找（不区分大小写），返回小写 DOI → 已入库论文
+	GetExistingByDOI(ctx context.Context, dois []string) (map[string]ExistingPaper, error)
+}
+
+// Benchmark 存储。不存在时返回 ErrBenchmarkNotFound，software_id 指向的软件不存在时返回 ErrSoftwareNotFound
+type BenchmarkRepository interface {
+	List(ctx context.Context, page PageRequest) (*Page[models.Benchmark], error)
+	ListBySoftwareID(ctx context.Context, softwareID int) ([]models.Benchmark, error)
+	GetByID(ctx context.Context, id int) (*models.Benchmark, error)
+	Create(ctx context.Context, b *models.Benchmark) error
+	Update(ctx context.Context, b *models.Benchmark) error
+	Delete(ctx context.Context, id int) error
+}
+
+// 用户和 API key 的存储。用户不存在时返回 ErrUserNotFound，重名时返回 ErrUserNameConflict
+type UserRepository interface {
+	List(ctx context.Context) ([]models.User, error)
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByName(ctx context.Context, name string) (*models.User, error)
+	Create(ctx context.Context, u *models.User) error
+	// 更新角色和停用状态
+	Update(ctx context.Context, u *models.User) error
+	ListAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error)
+	// 登记 API key，只保存哈希；哈希已存在时返回 ErrAPIKeyConflict
+	CreateAPIKey(ctx context.Context, k *models.APIKey, hash string) error
+	// 吊销 API key，不存在或已吊销时返回 ErrAPIKeyNotFound
+	RevokeAPIKey(ctx context.Context, id int) error
+	// 按哈希查找未吊销的 key 及其未停用的用户，并记录使用时间；找不到时返回 ErrAPIKeyNotFound
+	Authenticate(ctx context.Context, hash string) (*models.User, int, error)
+}
+
+// 论文—软件关联的人工审核队列。记录不存在时返回 ErrReviewNotFound
+type ReviewRepository interface {
+	// 放入审核队列，已有记录（包括已拒绝的）时不做修改
+	Queue(ctx context.Context, r *models.PaperReview) error
+	// 审核队列（分页），status、software 为空时不过滤
+	Query(ctx context.Context, status, software string, page PageRequest) (*Page[models.PaperReview], error)
+	GetByID(ctx context.Context, id int64) (*models.PaperReview, error)
+	// 通过时把软件关联到论文，已处理过时返回 ErrReviewDone
+	Resolve(ctx context.Context, id int64, approve bool) error
+}
+
+// 软件、已发布论文和 Benchmark 的全文检索
+type SearchRepository interface {
+	// 结果按相关度排序，types 为空时检索全部实体（SearchTypes），同时返回命中总数
+	Search(ctx context.Context, q string, types []string, limit, offset int) ([]models.SearchResult, int, error)
+}
+
+// 异步抓取任务的存储。不存在时返回 ErrCrawlJobNotFound
+type CrawlJobRepository interface {
+	// 新建任务，由 job.InstanceID 持有、租约为 lease
+	Create(ctx context.Context, job *models.CrawlJob, lease time.Duration) error
+	// 保存状态、进度和计数；任务已不归 job.InstanceID 持有或已结束时返回 ErrCrawlJobLeaseLost
+	Update(ctx context.Context, job *models.CrawlJob) error
+	GetByID(ctx context.Context, id int64) (*models.CrawlJob, error)
+	// 请求取消，任务已结束时返回该任务和 ErrCrawlJobFinished
+	RequestCancel(ctx context.Context, id int64) (*models.CrawlJob, error)
+	CancelRequested(ctx context.Context, id int64) (bool, error)
+	// 续约 instanceID 持有的排队中和运行中的任务
+	RenewLeases(ctx context.Context, instanceID string, lease time.Duration) (int64, error)
+	// 租约已过期的排队中和运行中的任务标记为失败
+	FailExpired(ctx context.Context) (int64, error)
+	// 认领最多 limit 个被中断的任务，改回 queued 并由 instanceID 持有
+	ClaimInterrupted(ctx context.Context, instanceID string, lease time.Duration, limit int) ([]*models.CrawlJob, error)
+}
+
+// 定时抓取执行记录的存储
+type CrawlRunRepository interface {
+	Create(ctx context.Context, run *models.CrawlRun) error
+	Update(ctx context.Context, run *models.CrawlRun) error
+	Query(ctx context.Context, page PageRequest) (*Page[models.CrawlRun], error)
+	// 最近一次执行记录，state 为空时不限状态；没有记录时返回 sql.ErrNoRows
+	GetLatest(ctx context.Context, state string) (*models.CrawlRun, error)
+	// 没有结束的记录标记为失败，只应在持有定时抓取锁时调用
+	FailUnfinished(ctx context.Context) (int64, error)
+}
+
+// 各软件增量抓取状态的存储
+type CrawlStateRepository interface {
+	// 从未抓取过时返回 sql.ErrNoRows
+	Get(ctx context.Context, softwareName string) (*models.SoftwareCrawlState, error)
+	// 软件已被删除时什么也不做
+	Save(ctx context.Context, softwareName string, state *models.SoftwareCrawlState) error
+}
+
+// 跨实例的互斥锁
+type Locker interface {
+	// 尝试获取锁，拿不到时立即返回 ok=false；持有锁的进程退出后锁自动释放
+	TryLock(ctx context.Context, key int64) (release func(), ok bool, err error)
+}
+
+// handler 使用的各存储
+type Repositories struct {
+	Software   SoftwareRepository
+	Papers     PaperRepository
+	Benchmarks BenchmarkRepository
+	Users      UserRepository
+	Reviews    ReviewRepository
+	Search     SearchRepository
+	CrawlJobs  CrawlJobRepository
+	CrawlRuns  CrawlRunRepository
+	CrawlState CrawlStateRepository
+	Locks      Locker
+}
+
+// 基于 PostgreSQL 的存储，queryTimeout 为单次数据库调用的超时（database.query_timeout），0 表示不限制
+func NewPostgres(db *sql.DB, queryTimeout time.Duration) Repositories {
+	return Repositories{
+		Software:   NewPostgresSoftwareRepository(db, queryTimeout),
+		Papers:     NewPostgresPaperRepository(db, queryTimeout),
+		Benchmarks: NewPostgresBenchmarkRepository(db, queryTimeout),
+		Users:      NewPostgresUserRepository(db, queryTimeout),
+		Reviews:    NewPostgresReviewRepository(db, queryTimeout),
+		Search:     NewPostgresSearchRepository(db, queryTimeout),
+		CrawlJobs:  NewPostgresCrawlJobRepository(db, queryTimeout),
+		CrawlRuns:  NewPostgresCrawlRunRepository(db, queryTimeout),
+		CrawlState: NewPostgresCrawlStateRepository(db, queryTimeout),
+		Locks:      NewPostgresLocker(db, queryTimeout),
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/models"
+)
+
+// 基于 PostgreSQL 的 ReviewRepository
+type PostgresReviewRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresReviewRepository(db *sql.DB, queryTimeout time.Duration) *PostgresReviewRepository {
+	return &PostgresReviewRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// 审核记录已处理过（已通过或已拒绝）
+var ErrReviewDone = apperr.New(apperr.Conflict, "review already resolved")
+
+var reviewSort = sortSpec{
+	columns: map[string]sortColumn{
+		"id":         {expr: "r.id", cast: "::bigint"},
+		"confidence": {expr: "r.confidence", cast: "::real"},
+		"created_at": {expr: "r.created_at", cast: "::timestamp"},
+	},
+	defaultSort: "id",
+	id:          sortColumn{expr: "r.id", cast: "::bigint"},
+}
+
+func reviewSortKey(r models.PaperReview, sort string) (string, string) {
+	id := strconv.FormatInt(r.ID, 10)
+	switch sort {
+	case "confidence":
+		return strconv.FormatFloat(r.Confidence, 'g', -1, 32), id
+	case "created_at":
+		return r.CreatedAt.Format(time.RFC3339Nano), id
+	}
+	return id, id
+}
+
+const reviewColumns = `r.id, r.paper_id, p.title, r.software_id, s.name, r.confidence, r.reasons, r.status, r.created_at, r.reviewed_at`
+
+// 审核记录连同论文标题和软件名
+const reviewFrom = `
+	FROM paper_review r
+	JOIN paper p ON p.id = r.paper_id
+	JOIN software s ON s.id = r.software_id`
+
+func scanReview(row rowScanner) (models.PaperReview, error) {
+	var r models.PaperReview
+	err := row.Scan(&r.ID, &r.PaperID, &r.PaperTitle, &r.SoftwareID, &r.SoftwareName, &r.Confidence, pq.Array(&r.Reasons),
+		&r.Status, &r.CreatedAt, &r.ReviewedAt)
+	return r, err
+}
+
+// 放入审核队列：论文 $1、软件 $2（ID）、置信度 $3、原因 $4，已有记录（包括已拒绝的）时不做修改
+const queuePaperReviewSQL = `
+	INSERT INTO paper_review (paper_id, software_id, confidence, reasons)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (paper_id, software_id) DO NOTHING`
+
+// 把论文—软件关联放入审核队列，已有记录（包括已拒绝的）时不做修改
+func (r *PostgresReviewRepository) Queue(ctx context.Context, rv *models.PaperReview) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, queuePaperReviewSQL, rv.PaperID, rv.SoftwareID, rv.Confidence, pq.Array(nonNil(rv.Reasons)))
+	return err
+}
+
+// 审核队列（分页），status、software 为空时不过滤
+func (r *PostgresReviewRepository) Query(ctx context.Context, status, software string, page PageRequest) (*Page[models.PaperReview], error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	where := reviewFrom + `
+		WHERE 1=1
+	`
+	var args []interface{}
+	argID := 1
+
+	if status != "" {
+		where += fmt.Sprintf(" AND r.status = $%d", argID)
+		args = append(args, status)
+		argID++
+	}
+	if software != "" {
+		where += fmt.Sprintf(" AND LOWER(s.name) = LOWER($%d)", argID)
+		args = append(args, software)
+		argID++
+	}
+
+	total, err := countRows(ctx, r.db, "SELECT COUNT(*)"+where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := page.apply("SELECT "+reviewColumns+where, args, reviewSort)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []models.PaperReview
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newPage(page, reviews, total, reviewSortKey), nil
+}
+
+// 按 ID 获取审核记录，不存在时返回 ErrReviewNotFound
+func (r *PostgresReviewRepository) GetByID(ctx context.Context, id int64) (*models.PaperReview, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rv, err := scanReview(r.db.QueryRowContext(ctx,
+		`SELECT `+reviewColumns+reviewFrom+` WHERE r.id = $1`, id))
+	if err != nil {
+		return nil, notFoundAs(err, ErrReviewNotFound)
+	}
+	return &rv, nil
+}
+
+// 处理审核记录：通过时把软件关联到论文。记录不存在返回 ErrReviewNotFound，已处理过返回 ErrReviewDone
+func (r *PostgresReviewRepository) Resolve(ctx context.Context, id int64, approve bool) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var paperID, status string
+	var softwareID int
+	var confidence float64
+	err = tx.QueryRowContext(ctx,
+		`SELECT paper_id, software_id, confidence, status FROM paper_review WHERE id = $1 FOR UPDATE`, id,
+	).Scan(&paperID, &softwareID, &confidence, &status)
+	if err != nil {
+		return notFoundAs(err, ErrReviewNotFound)
+	}
+	if status != models.ReviewPending {
+		return ErrReviewDone
+	}
+
+	newStatus := models.ReviewRejected
+	if approve {
+		newStatus = models.ReviewApproved
+		if _, err := tx.ExecContext(ctx, linkPaperSoftwareSQL, paperID, softwareID, LinkSourceReview, confidence); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE paper_review SET status = $1, reviewed_at = NOW() WHERE id = $2`, newStatus, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+// 基于 PostgreSQL 的 SearchRepository
+type PostgresSearchRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresSearchRepository(db *sql.DB, queryTimeout time.Duration) *PostgresSearchRepository {
+	return &PostgresSearchRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// 各实体参与检索的子查询：类型、id、标题、用于生成摘要的正文、tsvector
+var searchSources = map[string]string{
+	"software": `SELECT 'software' AS type, id::text AS id, name AS title,
+		concat_ws(' ', abstract, immutable_array_to_string(tags, ' ')) AS body, search_vector
+		FROM software`,
+	"paper": `SELECT 'paper' AS type, id AS id, title,
+		concat_ws(' ', abstract, immutable_array_to_string(authors, ', ')) AS body, search_vector
+		FROM paper p WHERE ` + paperPublished,
+	"benchmark": `SELECT 'benchmark' AS type, id::text AS id, COALESCE(name, '') AS title,
+		COALESCE(dataset, '') AS body, search_vector
+		FROM benchmark`,
+}
+
+// 作者按 'simple' 配置索引（不做词干化），查询同时按 'english' 和 'simple' 解析后取并集，
+// 否则 "Jones" 会被词干化成 jone，匹配不到作者中的 jones
+const searchQuery = `websearch_to_tsquery('english', $1) || websearch_to_tsquery('simple', $1)`
+
+// 生成摘要前先对正文做 HTML 转义，摘要中只有 <mark> 是标记，客户端可以直接按 HTML 渲染
+const searchEscapedBody = `replace(replace(replace(replace(replace(h.body,
+		'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+
+// 可检索的实体类型
+var SearchTypes = []string{"software", "paper", "benchmark"}
+
+// 全文检索，结果按相关度排序，types 为空时检索全部实体
+func (r *PostgresSearchRepository) Search(ctx context.Context, q string, types []string, limit, offset int) ([]models.SearchResult, int, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	if len(types) == 0 {
+		types = SearchTypes
+	}
+
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		src, ok := searchSources[t]
+		if !ok {
+			return nil, 0, fmt.Errorf("unknown search type: %s", t)
+		}
+		parts = append(parts, fmt.Sprintf(`SELECT s.type, s.id, s.title, s.body, ts_rank_cd(s.search_vector, q.query) AS rank
+			FROM (%s) s, q WHERE s.search_vector @@ q.query`, src))
+	}
+	hits := `WITH q AS (SELECT ` + searchQuery + ` AS query),
+		hits AS (` + strings.Join(parts, " UNION ALL ") + `)`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, hits+` SELECT COUNT(*) FROM hits`, q).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// 先分页再生成 ts_headline，避免对全部命中结果计算摘要
+	query := hits + `
+		SELECT h.type, h.id, h.title,
+		       ts_headline('english', ` + searchEscapedBody + `, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10'),
+		       h.rank
+		FROM (SELECT * FROM hits ORDER BY rank DESC, type, id LIMIT $2 OFFSET $3) h, q
+		ORDER BY h.rank DESC, h.type, h.id`
+
+	rows, err := r.db.QueryContext(ctx, query, q, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var res models.SearchResult
+		if err := rows.Scan(&res.Type, &res.ID, &res.Title, &res.Snippet, &res.Rank); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, res)
+	}
+	return results, total, rows.Err()
+}
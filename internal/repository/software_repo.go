@@ -2,47 +2,111 @@ package repository
 
 import (
 	"context"
-
+	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/lib/pq"
+	"hpc-site/internal/apperr"
 	"hpc-site/internal/models"
-	"hpc-site/pkg"
 )
 
-// 软件查询（支持过滤）
-func QuerySoftware(ctx context.Context, name, category, tag, search string) ([]models.Software, error) {
-	query := `
-		SELECT id, name, abstract, homepage, github, categories, tags, created_at
+// 基于 PostgreSQL 的 SoftwareRepository
+type PostgresSoftwareRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresSoftwareRepository(db *sql.DB, queryTimeout time.Duration) *PostgresSoftwareRepository {
+	return &PostgresSoftwareRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// 软件列表可排序的列
+var softwareSort = sortSpec{
+	columns: map[string]sortColumn{
+		"id":         {expr: "id", cast: "::int"},
+		"name":       {expr: "name", cast: "::text"},
+		"created_at": {expr: "created_at", cast: "::timestamp"},
+	},
+	defaultSort: "id",
+	id:          sortColumn{expr: "id", cast: "::int"},
+}
+
+func softwareSortKey(s models.Software, sort string) (string, string) {
+	id := strconv.Itoa(s.ID)
+	switch sort {
+	case "name":
+		return s.Name, id
+	case "created_at":
+		return s.CreatedAt.Format(time.RFC3339Nano), id
+	}
+	return id, id
+}
+
+// 查询软件时统一使用的列，与 scanSoftware 的顺序一致
+const softwareColumns = `id, name, abstract, homepage, github, categories, tags, aliases, exclude_terms, created_at`
+
+func scanSoftware(row rowScanner) (models.Software, error) {
+	var s models.Software
+	err := row.Scan(&s.ID, &s.Name, &s.Abstract, &s.Homepage, &s.Github,
+		pq.Array(&s.Categories), pq.Array(&s.Tags), pq.Array(&s.Aliases), pq.Array(&s.ExcludeTerms), &s.CreatedAt)
+	return s, err
+}
+
+// 软件过滤条件，零值表示不过滤
+type SoftwareFilter struct {
+	Name     string // 软件名（不区分大小写）
+	Category string
+	Tag      string
+	Search   string // 名称和简介模糊匹配
+}
+
+// 软件查询（支持过滤和分页）
+func (r *PostgresSoftwareRepository) Query(ctx context.Context, filter SoftwareFilter, page PageRequest) (*Page[models.Software], error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	where := `
 		FROM software
 		WHERE 1=1
 	`
 	var args []interface{}
 	argID := 1
 
-	if name != "" {
-		query += fmt.Sprintf(" AND LOWER(name) = LOWER($%d)", argID)
-		args = append(args, name)
+	if filter.Name != "" {
+		where += fmt.Sprintf(" AND LOWER(name) = LOWER($%d)", argID)
+		args = append(args, filter.Name)
 		argID++
 	}
-	if category != "" {
-		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM unnest(categories) c WHERE LOWER(c) = LOWER($%d))", argID)
-		args = append(args, category)
+	if filter.Category != "" {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM unnest(categories) c WHERE LOWER(c) = LOWER($%d))", argID)
+		args = append(args, filter.Category)
 		argID++
 	}
-	if tag != "" {
-		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM unnest(tags) t WHERE LOWER(t) = LOWER($%d))", argID)
-		args = append(args, tag)
+	if filter.Tag != "" {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM unnest(tags) t WHERE LOWER(t) = LOWER($%d))", argID)
+		args = append(args, filter.Tag)
 		argID++
 	}
-	if search != "" {
-		query += fmt.Sprintf(" AND (name ILIKE $%d OR abstract ILIKE $%d)", argID, argID)
-		args = append(args, "%"+search+"%")
+	if filter.Search != "" {
+		where += fmt.Sprintf(" AND (name ILIKE $%d OR abstract ILIKE $%d)", argID, argID)
+		args = append(args, "%"+filter.Search+"%")
 		argID++
 	}
 
-	query += " ORDER BY id"
+	total, err := countRows(ctx, r.db, "SELECT COUNT(*)"+where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := page.apply("SELECT "+softwareColumns+where, args, softwareSort)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := pkg.DB.QueryContext(ctx, query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -50,65 +114,127 @@ func QuerySoftware(ctx context.Context, name, category, tag, search string) ([]m
 
 	var softwares []models.Software
 	for rows.Next() {
-		var s models.Software
-		err := rows.Scan(&s.ID, &s.Name, &s.Abstract, &s.Homepage, &s.Github,
-			pq.Array(&s.Categories), pq.Array(&s.Tags), &s.CreatedAt)
+		s, err := scanSoftware(rows)
 		if err != nil {
 			return nil, err
 		}
 		softwares = append(softwares, s)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return softwares, rows.Err()
+	return newPage(page, softwares, total, softwareSortKey), nil
 }
 
 // 根据 ID 获取软件
-func GetSoftwareByID(ctx context.Context, id int) (*models.Software, error) {
-	query := `
-		SELECT id, name, abstract, homepage, github, categories, tags, created_at
-		FROM software
-		WHERE id = $1
-	`
+func (r *PostgresSoftwareRepository) GetByID(ctx context.Context, id int) (*models.Software, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	s, err := scanSoftware(r.db.QueryRowContext(ctx, `SELECT `+softwareColumns+` FROM software WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFoundAs(err, ErrSoftwareNotFound)
+	}
+	return &s, nil
+}
 
-	row := pkg.DB.QueryRowContext(ctx, query, id)
+// 根据名称获取软件，不区分大小写
+func (r *PostgresSoftwareRepository) GetByName(ctx context.Context, name string) (*models.Software, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
 
-	var s models.Software
-	err := row.Scan(&s.ID, &s.Name, &s.Abstract, &s.Homepage, &s.Github,
-		pq.Array(&s.Categories), pq.Array(&s.Tags), &s.CreatedAt)
+	s, err := scanSoftware(r.db.QueryRowContext(ctx, `SELECT `+softwareColumns+` FROM software WHERE LOWER(name) = LOWER($1)`, name))
 	if err != nil {
-		return nil, err
+		return nil, notFoundAs(err, ErrSoftwareNotFound)
 	}
 	return &s, nil
 }
 
-// 查询某个软件相关的论文
-func GetPapersBySoftwareID(ctx context.Context, id int) ([]models.Paper, error) {
+var (
+	// 软件名唯一约束冲突
+	ErrSoftwareNameConflict = apperr.New(apperr.Conflict, "software name already exists")
+	// benchmark.software_id 外键为 ON DELETE RESTRICT，有 benchmark 的软件不能删除
+	ErrSoftwareHasBenchmarks = apperr.New(apperr.Conflict, "software still has benchmarks, delete them first")
+)
+
+// isUniqueViolation 判断是否为软件名唯一约束冲突（unique_software_idx、software_name_lower_idx 或列上的 UNIQUE）
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return true
+	}
+	return false
+}
+
+// 新增软件，回填 id 和 created_at
+func (r *PostgresSoftwareRepository) Create(ctx context.Context, s *models.Software) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	query := `
-    SELECT p.id, p.title, p.authors, p.abstract, p.url, p.software_names, p.created_at
-    FROM paper p
-    JOIN software s 
-      ON EXISTS (
-          SELECT 1
-          FROM unnest(p.software_names) sn
-          WHERE LOWER(sn) = LOWER(s.name)
-      )
-    WHERE s.id = $1
-`
-
-	rows, err := pkg.DB.QueryContext(ctx, query, id)
-	if err != nil {
-		return nil, err
+		INSERT INTO software (name, abstract, homepage, github, categories, tags, aliases, exclude_terms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		s.Name, s.Abstract, s.Homepage, s.Github,
+		pq.Array(s.Categories), pq.Array(s.Tags), pq.Array(nonNil(s.Aliases)), pq.Array(nonNil(s.ExcludeTerms)),
+	).Scan(&s.ID, &s.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrSoftwareNameConflict
 	}
-	defer rows.Close()
+	return err
+}
 
-	var papers []models.Paper
-	for rows.Next() {
-		var p models.Paper
-		err := rows.Scan(&p.ID, &p.Title, pq.Array(&p.Authors), &p.Abstract, &p.URL, pq.Array(&p.SoftwareNames), &p.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-		papers = append(papers, p)
+// 更新软件的全部可编辑字段，软件不存在时返回 ErrSoftwareNotFound
+func (r *PostgresSoftwareRepository) Update(ctx context.Context, s *models.Software) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE software
+		SET name = $1, abstract = $2, homepage = $3, github = $4, categories = $5, tags = $6,
+		    aliases = $7, exclude_terms = $8
+		WHERE id = $9
+		RETURNING created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		s.Name, s.Abstract, s.Homepage, s.Github,
+		pq.Array(s.Categories), pq.Array(s.Tags), pq.Array(nonNil(s.Aliases)), pq.Array(nonNil(s.ExcludeTerms)), s.ID,
+	).Scan(&s.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrSoftwareNameConflict
+	}
+	return notFoundAs(err, ErrSoftwareNotFound)
+}
+
+// NOT NULL 的数组列不接受 nil
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// 删除软件，软件不存在时返回 ErrSoftwareNotFound，还有 benchmark 时返回 ErrSoftwareHasBenchmarks
+func (r *PostgresSoftwareRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM software WHERE id = $1`, id)
+	if isForeignKeyViolation(err) {
+		return ErrSoftwareHasBenchmarks
+	}
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSoftwareNotFound
 	}
-	return papers, rows.Err()
+	return nil
 }
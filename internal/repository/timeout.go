@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// 为一次仓储调用加上 timeout 的超时，0 表示不限制；ctx 自身的截止时间更早时以 ctx 为准
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// 是否因超时失败。ctx 是调用仓储时传入的 ctx（如请求的 ctx），仓储在其上再加查询超时。
+// lib/pq 在查询的 ctx 结束时取消正在执行的语句，返回 query_canceled 而不是 ctx 的错误，statement_timeout 也是这个错误码：
+// 此时 ctx 仍有效或已超时，说明是查询超时；ctx 已被取消（客户端断开、任务取消）则不算超时
+func IsTimeout(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "57014" {
+		return false
+	}
+	return !errors.Is(ctx.Err(), context.Canceled)
+}
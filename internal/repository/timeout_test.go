@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsTimeout(t *testing.T) {
+	alive := context.Background()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	queryCanceled := &pq.Error{Code: "57014", Message: "canceling statement due to user request"}
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"deadline", alive, fmt.Errorf("query: %w", context.DeadlineExceeded), true},
+		{"query timeout", alive, queryCanceled, true},
+		{"request deadline", expired, queryCanceled, true},
+		{"client gone", cancelled, queryCanceled, false},
+		{"cancelled", cancelled, context.Canceled, false},
+		{"other pq error", alive, &pq.Error{Code: "23505"}, false},
+		{"plain error", alive, errors.New("boom"), false},
+	} {
+		if got := IsTimeout(tc.ctx, tc.err); got != tc.want {
+			t.Errorf("%s: IsTimeout = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	ctx, cancel := withQueryTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("deadline = %v, %v", deadline, ok)
+	}
+
+	// 0 表示不限制
+	ctx, cancel = withQueryTimeout(context.Background(), 0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("zero timeout set a deadline")
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("cancel did not cancel the context")
+	}
+
+	// 调用方的截止时间更早时以调用方为准
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	want, _ := parent.Deadline()
+	ctx, cancel = withQueryTimeout(parent, time.Hour)
+	defer cancel()
+	if got, _ := ctx.Deadline(); !got.Equal(want) {
+		t.Errorf("deadline = %v, want the caller's %v", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hpc-site/internal/apperr"
+	"hpc-site/internal/models"
+)
+
+var (
+	ErrUserNameConflict = apperr.New(apperr.Conflict, "user name already exists")
+	ErrAPIKeyConflict   = apperr.New(apperr.Conflict, "api key already registered")
+)
+
+// 基于 PostgreSQL 的 UserRepository
+type PostgresUserRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration // 单次调用的超时，0 表示不限制
+}
+
+func NewPostgresUserRepository(db *sql.DB, queryTimeout time.Duration) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db, queryTimeout: queryTimeout}
+}
+
+const userColumns = `id, name, role, disabled, created_at`
+
+func scanUser(row rowScanner) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Name, &u.Role, &u.Disabled, &u.CreatedAt)
+	return u, err
+}
+
+// 全部用户，按 ID 升序
+func (r *PostgresUserRepository) List(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	u, err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFoundAs(err, ErrUserNotFound)
+	}
+	return &u, nil
+}
+
+func (r *PostgresUserRepository) GetByName(ctx context.Context, name string) (*models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	u, err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE name = $1`, name))
+	if err != nil {
+		return nil, notFoundAs(err, ErrUserNotFound)
+	}
+	return &u, nil
+}
+
+// 新增用户，回填 id 和 created_at
+func (r *PostgresUserRepository) Create(ctx context.Context, u *models.User) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO users (name, role, disabled) VALUES ($1, $2, $3) RETURNING id, created_at`,
+		u.Name, u.Role, u.Disabled,
+	).Scan(&u.ID, &u.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrUserNameConflict
+	}
+	return err
+}
+
+// 更新角色和停用状态，回填其余字段
+func (r *PostgresUserRepository) Update(ctx context.Context, u *models.User) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE users SET role = $1, disabled = $2 WHERE id = $3 RETURNING name, created_at`,
+		u.Role, u.Disabled, u.ID,
+	).Scan(&u.Name, &u.CreatedAt)
+	return notFoundAs(err, ErrUserNotFound)
+}
+
+// 用户的全部 API key（包括已吊销的），按 ID 升序
+func (r *PostgresUserRepository) ListAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, name, prefix, created_at, last_used_at, revoked_at
+		FROM api_keys WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// 登记 API key，回填 id 和 created_at
+func (r *PostgresUserRepository) CreateAPIKey(ctx context.Context, k *models.APIKey, hash string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash) VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		k.UserID, k.Name, k.Prefix, hash,
+	).Scan(&k.ID, &k.CreatedAt)
+	switch {
+	case isUniqueViolation(err):
+		return ErrAPIKeyConflict
+	case isForeignKeyViolation(err):
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func (r *PostgresUserRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// 校验 key 和更新 last_used_at 在同一条语句中完成
+func (r *PostgresUserRepository) Authenticate(ctx context.Context, hash string) (*models.User, int, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var u models.User
+	var keyID int
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE api_keys k SET last_used_at = NOW()
+		FROM users u
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND u.id = k.user_id AND NOT u.disabled
+		RETURNING u.id, u.name, u.role, u.disabled, u.created_at, k.id`, hash,
+	).Scan(&u.ID, &u.Name, &u.Role, &u.Disabled, &u.CreatedAt, &keyID)
+	if err != nil {
+		return nil, 0, notFoundAs(err, ErrAPIKeyNotFound)
+	}
+	return &u, keyID, nil
+}
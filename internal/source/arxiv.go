@@ -0,0 +1,260 @@
+package source
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+const (
+	DefaultArxivAPIURL   = "https://export.arxiv.org/api/query"
+	DefaultArxivPageSize = 100
+)
+
+// arXiv Atom 导出 API（export.arxiv.org/api/query）客户端
+type ArxivAPI struct {
+	BaseURL  string
+	PageSize int
+	Client   Doer
+}
+
+// baseURL 为空时使用官方地址，测试时可指向本地的 fixture 服务；
+// arXiv 要求请求间隔至少 3 秒，由 client 的限速保证
+func NewArxivAPI(baseURL string, client Doer) *ArxivAPI {
+	if baseURL == "" {
+		baseURL = DefaultArxivAPIURL
+	}
+	return &ArxivAPI{
+		BaseURL:  baseURL,
+		PageSize: DefaultArxivPageSize,
+		Client:   defaultDoer(client),
+	}
+}
+
+// Atom feed 结构，只声明需要的字段
+type atomFeed struct {
+	TotalResults int         `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	StartIndex   int         `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex"`
+	Entries      []atomEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"http://www.w3.org/2005/Atom id"`
+	Title     string `xml:"http://www.w3.org/2005/Atom title"`
+	Summary   string `xml:"http://www.w3.org/2005/Atom summary"`
+	Published string `xml:"http://www.w3.org/2005/Atom published"`
+	Updated   string `xml:"http://www.w3.org/2005/Atom updated"`
+	Authors   []struct {
+		Name string `xml:"http://www.w3.org/2005/Atom name"`
+	} `xml:"http://www.w3.org/2005/Atom author"`
+	Links []struct {
+		Href  string `xml:"href,attr"`
+		Rel   string `xml:"rel,attr"`
+		Title string `xml:"title,attr"`
+	} `xml:"http://www.w3.org/2005/Atom link"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"http://www.w3.org/2005/Atom category"`
+	DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+	JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	Comment    string `xml:"http://arxiv.org/schemas/atom comment"`
+}
+
+// http://arxiv.org/abs/2405.20629v2 → 2405.20629, 2
+var arxivEntryIDRe = regexp.MustCompile(`/abs/(.+?)(?:v(\d+))?$`)
+
+// 撤稿时 arXiv 在新版本的 comment 中写明，如 "This paper has been withdrawn by the author"、"Withdrawn"；
+// 只是提到其他被撤稿论文的（如 "Replaces withdrawn hep-lat/0512999"）不算
+var arxivWithdrawnRe = regexp.MustCompile(`(?i)^\s*withdrawn\b|\b(?:has been|is|was) withdrawn\b|\bwithdrawn (?:by|due to|because)\b`)
+
+func (a *ArxivAPI) Name() string { return "arxiv" }
+
+// 检索与软件相关的全部论文
+func (a *ArxivAPI) Search(ctx context.Context, q Query) ([]models.Paper, error) {
+	return a.SearchAll(ctx, q.arxiv())
+}
+
+// 按查询语句分页检索，结果按提交时间倒序；返回本页论文和结果总数
+func (a *ArxivAPI) SearchPage(ctx context.Context, query string, start int) ([]models.Paper, int, error) {
+	params := url.Values{}
+	params.Set("search_query", query)
+	params.Set("start", strconv.Itoa(start))
+	params.Set("max_results", strconv.Itoa(a.PageSize))
+	params.Set("sortBy", "submittedDate")
+	params.Set("sortOrder", "descending")
+
+	feed, err := a.query(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	return feed.papers(), feed.TotalResults, nil
+}
+
+// 检索某个查询语句下的全部论文
+func (a *ArxivAPI) SearchAll(ctx context.Context, query string) ([]models.Paper, error) {
+	return a.searchAll(ctx, query, time.Time{})
+}
+
+// 只检索 since 之后提交的论文，遇到更早的结果即停止翻页
+func (a *ArxivAPI) SearchSince(ctx context.Context, q Query, since time.Time) ([]models.Paper, error) {
+	return a.searchAll(ctx, q.arxiv(), since)
+}
+
+// since 为零值时翻完全部结果
+func (a *ArxivAPI) searchAll(ctx context.Context, query string, since time.Time) ([]models.Paper, error) {
+	var all []models.Paper
+	seen := make(map[string]bool)
+	for start := 0; ; start += a.PageSize {
+		papers, total, err := a.SearchPage(ctx, query, start)
+		if err != nil {
+			if start == 0 {
+				return nil, err
+			}
+			log.Printf("arXiv API 第 %d 条起的结果获取失败，使用已获取的 %d 条: %v", start, len(all), err)
+			break
+		}
+		reachedOld := false
+		for _, p := range papers {
+			if !since.IsZero() && submittedBefore(p, since) {
+				reachedOld = true
+				continue
+			}
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				all = append(all, p)
+			}
+		}
+		log.Printf("arXiv API start=%d 解析出 %d 条，共 %d 条", start, len(papers), total)
+		if reachedOld {
+			log.Printf("arXiv API 已翻到 %s 之前提交的论文，停止翻页", since.Format(time.DateOnly))
+			break
+		}
+		if len(papers) == 0 || start+a.PageSize >= total {
+			break
+		}
+	}
+	return all, nil
+}
+
+// 论文首次提交时间早于 t；时间无法解析时视为不早于 t，保留该论文
+func submittedBefore(p models.Paper, t time.Time) bool {
+	published, err := time.Parse(time.RFC3339, p.PublishedTime)
+	return err == nil && published.Before(t)
+}
+
+// 按 arXiv ID 获取单篇论文的元数据
+func (a *ArxivAPI) Fetch(ctx context.Context, id string) (models.Paper, error) {
+	params := url.Values{}
+	params.Set("id_list", id)
+	params.Set("max_results", "1")
+
+	feed, err := a.query(ctx, params)
+	if err != nil {
+		return models.Paper{}, err
+	}
+	papers := feed.papers()
+	if len(papers) == 0 {
+		return models.Paper{}, fmt.Errorf("%w: arxiv %s", ErrNotFound, id)
+	}
+	return papers[0], nil
+}
+
+var _ IncrementalSource = (*ArxivAPI)(nil)
+
+func (a *ArxivAPI) query(ctx context.Context, params url.Values) (*atomFeed, error) {
+	u := a.BaseURL + "?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("arxiv api: unexpected status %d for %s", resp.StatusCode, u)
+	}
+
+	var feed atomFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("arxiv api: decode feed: %w", err)
+	}
+	// 查询参数有误时 API 返回一条 id 为 .../api/errors#... 的 entry
+	if len(feed.Entries) == 1 && strings.Contains(feed.Entries[0].ID, "/api/errors") {
+		return nil, fmt.Errorf("arxiv api: %s", collapseSpace(feed.Entries[0].Summary))
+	}
+	return &feed, nil
+}
+
+func (f *atomFeed) papers() []models.Paper {
+	papers := make([]models.Paper, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		if p, ok := e.paper(); ok {
+			papers = append(papers, p)
+		}
+	}
+	return papers
+}
+
+// Atom API 只返回最新版本：Version 为最新版本号，PublishedTime 为 v1 的提交时间，
+// UpdatedTime 为最新版本的提交时间，各历史版本不单独记录
+func (e *atomEntry) paper() (models.Paper, bool) {
+	m := arxivEntryIDRe.FindStringSubmatch(strings.TrimSpace(e.ID))
+	if m == nil || strings.TrimSpace(e.Title) == "" {
+		return models.Paper{}, false
+	}
+	version, _ := strconv.Atoi(m[2])
+
+	p := models.Paper{
+		ID:            m[1],
+		Source:        "arxiv",
+		ExternalIDs:   map[string]string{"arxiv": m[1]},
+		Title:         collapseSpace(e.Title),
+		Abstract:      collapseSpace(e.Summary),
+		URL:           "https://arxiv.org/abs/" + m[1],
+		PublishedTime: strings.TrimSpace(e.Published),
+		UpdatedTime:   strings.TrimSpace(e.Updated),
+		Version:       version,
+		DOI:           strings.TrimSpace(e.DOI),
+		JournalRef:    collapseSpace(e.JournalRef),
+		Withdrawn:     arxivWithdrawnRe.MatchString(e.Comment),
+		Authors:       make([]string, 0, len(e.Authors)),
+		Categories:    make([]string, 0, len(e.Categories)),
+	}
+	if p.DOI != "" {
+		p.ExternalIDs["doi"] = p.DOI
+	}
+	for _, author := range e.Authors {
+		if name := collapseSpace(author.Name); name != "" {
+			p.Authors = append(p.Authors, name)
+		}
+	}
+	for _, c := range e.Categories {
+		if c.Term != "" {
+			p.Categories = append(p.Categories, c.Term)
+		}
+	}
+	for _, l := range e.Links {
+		if l.Title == "pdf" {
+			p.Pdf = l.Href
+		}
+	}
+	return p, true
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
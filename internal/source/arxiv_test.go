@@ -0,0 +1,249 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 按请求参数返回 testdata 下保存的 Atom feed，记录收到的查询参数
+type arxivFixture struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []string
+	status   int
+}
+
+func newArxivFixture(t *testing.T) (*arxivFixture, *ArxivAPI) {
+	f := &arxivFixture{t: t, status: http.StatusOK}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	api := NewArxivAPI(srv.URL+"/api/query", srv.Client())
+	api.PageSize = 2
+	return f, api
+}
+
+func (f *arxivFixture) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.RawQuery)
+	status := f.status
+	f.mu.Unlock()
+
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+
+	var file string
+	switch {
+	case q.Get("start") == "-1":
+		file = "arxiv_error.xml"
+	case q.Get("id_list") == "2405.20629":
+		file = "arxiv_page1.xml"
+	case q.Get("id_list") != "":
+		file = "arxiv_empty.xml"
+	case q.Get("start") == "0":
+		file = "arxiv_page1.xml"
+	case q.Get("start") == "2":
+		file = "arxiv_page2.xml"
+	default:
+		file = "arxiv_empty.xml"
+	}
+	body, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		f.t.Errorf("read fixture: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml")
+	w.Write(body)
+}
+
+func (f *arxivFixture) queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func TestArxivSearchPageMapsEntries(t *testing.T) {
+	_, api := newArxivFixture(t)
+
+	papers, total, err := api.SearchPage(context.Background(), `all:"LAMMPS"`, 0)
+	if err != nil {
+		t.Fatalf("SearchPage: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	if len(papers) != 2 {
+		t.Fatalf("got %d papers, want 2", len(papers))
+	}
+
+	p := papers[0]
+	want := map[string]any{
+		"ID":            "2405.20629",
+		"Source":        "arxiv",
+		"Title":         "Scaling Molecular Dynamics with LAMMPS on Exascale Systems",
+		"Abstract":      "We report on porting LAMMPS to GPU-accelerated exascale machines.",
+		"URL":           "https://arxiv.org/abs/2405.20629",
+		"Pdf":           "http://arxiv.org/pdf/2405.20629v2",
+		"PublishedTime": "2024-05-29T15:33:28Z",
+		"UpdatedTime":   "2024-05-31T10:12:45Z",
+		"Version":       2,
+		"DOI":           "10.1000/XYZ.2024.001",
+		"JournalRef":    "J. Comput. Phys. 512 (2024) 113101",
+		"Withdrawn":     false,
+		"Authors":       []string{"Alice Jones", "Bob Smith"},
+		"Categories":    []string{"physics.comp-ph", "cs.DC"},
+		"ExternalIDs":   map[string]string{"arxiv": "2405.20629", "doi": "10.1000/XYZ.2024.001"},
+	}
+	v := reflect.ValueOf(p)
+	for field, w := range want {
+		if got := v.FieldByName(field).Interface(); !reflect.DeepEqual(got, w) {
+			t.Errorf("%s = %#v, want %#v", field, got, w)
+		}
+	}
+}
+
+// Atom API 只给出最新版本：版本号取自 entry id，published 是 v1，updated 是最新版本
+func TestArxivVersions(t *testing.T) {
+	_, api := newArxivFixture(t)
+
+	papers, _, err := api.SearchPage(context.Background(), `all:"LAMMPS"`, 0)
+	if err != nil {
+		t.Fatalf("SearchPage: %v", err)
+	}
+	withdrawn := papers[1]
+	if withdrawn.ID != "2403.01234" || withdrawn.Version != 3 {
+		t.Errorf("got %s v%d, want 2403.01234 v3", withdrawn.ID, withdrawn.Version)
+	}
+	if withdrawn.PublishedTime != "2024-03-02T09:30:00Z" || withdrawn.UpdatedTime != "2024-04-02T08:00:00Z" {
+		t.Errorf("published/updated = %q/%q", withdrawn.PublishedTime, withdrawn.UpdatedTime)
+	}
+	// 没有 DOI 时 external_ids 只有 arXiv ID，也没有 PDF 链接
+	if withdrawn.DOI != "" || len(withdrawn.ExternalIDs) != 1 || withdrawn.Pdf != "" {
+		t.Errorf("unexpected DOI/external ids/pdf: %q %v %q", withdrawn.DOI, withdrawn.ExternalIDs, withdrawn.Pdf)
+	}
+}
+
+func TestArxivWithdrawn(t *testing.T) {
+	_, api := newArxivFixture(t)
+
+	papers, err := api.SearchAll(context.Background(), `all:"LAMMPS"`)
+	if err != nil {
+		t.Fatalf("SearchAll: %v", err)
+	}
+	got := map[string]bool{}
+	for _, p := range papers {
+		got[p.ID] = p.Withdrawn
+	}
+	want := map[string]bool{
+		"2405.20629":      false,
+		"2403.01234":      true,  // comment 是撤稿声明
+		"hep-lat/0601001": false, // 只是提到另一篇被撤稿的论文
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withdrawn = %v, want %v", got, want)
+	}
+
+	for comment, want := range map[string]bool{
+		"Withdrawn":                               true,
+		"withdrawn due to a crucial sign error":   true,
+		"This submission has been withdrawn":      true,
+		"The paper is withdrawn by arXiv admin":   true,
+		"12 pages; replaces withdrawn 1234.56789": false,
+		"": false,
+	} {
+		e := atomEntry{ID: "http://arxiv.org/abs/1234.5678v2", Title: "t", Comment: comment}
+		p, _ := e.paper()
+		if p.Withdrawn != want {
+			t.Errorf("comment %q: withdrawn = %v, want %v", comment, p.Withdrawn, want)
+		}
+	}
+}
+
+func TestArxivSearchAllPages(t *testing.T) {
+	f, api := newArxivFixture(t)
+
+	papers, err := api.SearchAll(context.Background(), `all:"LAMMPS"`)
+	if err != nil {
+		t.Fatalf("SearchAll: %v", err)
+	}
+	var ids []string
+	for _, p := range papers {
+		ids = append(ids, p.ID)
+	}
+	if want := []string{"2405.20629", "2403.01234", "hep-lat/0601001"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	// 共 3 条、每页 2 条，只请求两页，结果按提交时间倒序
+	queries := f.queries()
+	if len(queries) != 2 {
+		t.Fatalf("got %d requests, want 2: %v", len(queries), queries)
+	}
+	for i, start := range []string{"start=0", "start=2"} {
+		for _, param := range []string{start, "max_results=2", "sortBy=submittedDate", "sortOrder=descending", "search_query=all%3A%22LAMMPS%22"} {
+			if !strings.Contains(queries[i], param) {
+				t.Errorf("request %d %q missing %s", i, queries[i], param)
+			}
+		}
+	}
+}
+
+func TestArxivSearchSinceStopsAtOlderPapers(t *testing.T) {
+	f, api := newArxivFixture(t)
+
+	since := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	papers, err := api.SearchSince(context.Background(), NewQuery("LAMMPS", nil, nil), since)
+	if err != nil {
+		t.Fatalf("SearchSince: %v", err)
+	}
+	if len(papers) != 1 || papers[0].ID != "2405.20629" {
+		t.Errorf("got %v, want only 2405.20629", papers)
+	}
+	if n := len(f.queries()); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestArxivFetch(t *testing.T) {
+	_, api := newArxivFixture(t)
+
+	p, err := api.Fetch(context.Background(), "2405.20629")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if p.ID != "2405.20629" {
+		t.Errorf("ID = %q", p.ID)
+	}
+
+	if _, err := api.Fetch(context.Background(), "9999.99999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Fetch missing paper: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestArxivErrors(t *testing.T) {
+	f, api := newArxivFixture(t)
+
+	// API 以一条 errors entry 报告参数错误
+	_, _, err := api.SearchPage(context.Background(), `all:"LAMMPS"`, -1)
+	if err == nil || !strings.Contains(err.Error(), "start must be non-negative") {
+		t.Errorf("err = %v, want the API error message", err)
+	}
+
+	f.status = http.StatusServiceUnavailable
+	if _, err := api.SearchAll(context.Background(), `all:"LAMMPS"`); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("err = %v, want unexpected status 503", err)
+	}
+}
@@ -0,0 +1,181 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"hpc-site/internal/models"
+)
+
+const (
+	DefaultCrossrefURL = "https://api.crossref.org"
+	crossrefPageSize   = 100
+	crossrefMaxResults = 500 // Crossref 按相关度返回，越往后越不相关，只取前若干条
+)
+
+// Crossref REST API 客户端，论文 ID 使用 "doi:" 前缀的 DOI
+type Crossref struct {
+	BaseURL    string
+	Mailto     string
+	MaxResults int
+	Client     Doer
+}
+
+func NewCrossref(baseURL string, client Doer) *Crossref {
+	if baseURL == "" {
+		baseURL = DefaultCrossrefURL
+	}
+	return &Crossref{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		MaxResults: crossrefMaxResults,
+		Client:     defaultDoer(client),
+	}
+}
+
+func (c *Crossref) Name() string { return "crossref" }
+
+type crossrefWork struct {
+	DOI    string   `json:"DOI"`
+	Title  []string `json:"title"`
+	URL    string   `json:"URL"`
+	Author []struct {
+		Given  string `json:"given"`
+		Family string `json:"family"`
+		Name   string `json:"name"`
+	} `json:"author"`
+	Abstract       string   `json:"abstract"`
+	ContainerTitle []string `json:"container-title"`
+	Subject        []string `json:"subject"`
+	Published      struct {
+		DateParts [][]int `json:"date-parts"`
+	} `json:"published"`
+	Link []struct {
+		URL         string `json:"URL"`
+		ContentType string `json:"content-type"`
+	} `json:"link"`
+}
+
+// 检索与软件相关的论文，按 cursor 深度分页，最多 MaxResults 条
+func (c *Crossref) Search(ctx context.Context, q Query) ([]models.Paper, error) {
+	var papers []models.Paper
+	cursor := "*"
+	for len(papers) < c.MaxResults {
+		params := url.Values{}
+		params.Set("query.bibliographic", q.plain())
+		params.Set("rows", strconv.Itoa(crossrefPageSize))
+		params.Set("cursor", cursor)
+		if c.Mailto != "" {
+			params.Set("mailto", c.Mailto)
+		}
+
+		var resp struct {
+			Message struct {
+				NextCursor string         `json:"next-cursor"`
+				Items      []crossrefWork `json:"items"`
+			} `json:"message"`
+		}
+		if err := getJSON(ctx, c.Client, c.BaseURL+"/works?"+params.Encode(), &resp); err != nil {
+			if len(papers) == 0 {
+				return nil, fmt.Errorf("crossref: %w", err)
+			}
+			break
+		}
+		for _, w := range resp.Message.Items {
+			if p, ok := w.paper(); ok {
+				papers = append(papers, p)
+			}
+		}
+		if len(resp.Message.Items) < crossrefPageSize || resp.Message.NextCursor == "" {
+			break
+		}
+		cursor = resp.Message.NextCursor
+	}
+	if len(papers) > c.MaxResults {
+		papers = papers[:c.MaxResults]
+	}
+	return papers, nil
+}
+
+// 按 DOI 获取单篇论文，id 可带 "doi:" 前缀
+func (c *Crossref) Fetch(ctx context.Context, id string) (models.Paper, error) {
+	doi := normalizeDOI(id)
+	u := c.BaseURL + "/works/" + url.PathEscape(doi)
+	if c.Mailto != "" {
+		u += "?mailto=" + url.QueryEscape(c.Mailto)
+	}
+
+	var resp struct {
+		Message crossrefWork `json:"message"`
+	}
+	if err := getJSON(ctx, c.Client, u, &resp); err != nil {
+		return models.Paper{}, fmt.Errorf("crossref %s: %w", doi, err)
+	}
+	p, ok := resp.Message.paper()
+	if !ok {
+		return models.Paper{}, fmt.Errorf("%w: crossref %s", ErrNotFound, doi)
+	}
+	return p, nil
+}
+
+var _ PaperSource = (*Crossref)(nil)
+
+// Crossref 的摘要是 JATS XML 片段
+var jatsTagRe = regexp.MustCompile(`<[^>]+>`)
+
+func (w *crossrefWork) paper() (models.Paper, bool) {
+	doi := normalizeDOI(w.DOI)
+	if doi == "" || len(w.Title) == 0 || strings.TrimSpace(w.Title[0]) == "" {
+		return models.Paper{}, false
+	}
+
+	p := models.Paper{
+		ID:          "doi:" + doi,
+		Source:      "crossref",
+		ExternalIDs: map[string]string{"doi": doi},
+		Title:       collapseSpace(w.Title[0]),
+		Abstract:    collapseSpace(jatsTagRe.ReplaceAllString(w.Abstract, " ")),
+		URL:         w.URL,
+		DOI:         doi,
+		Categories:  w.Subject,
+		Authors:     make([]string, 0, len(w.Author)),
+	}
+	if p.URL == "" {
+		p.URL = "https://doi.org/" + doi
+	}
+	if len(w.ContainerTitle) > 0 {
+		p.JournalRef = collapseSpace(w.ContainerTitle[0])
+	}
+	if len(w.Published.DateParts) > 0 {
+		p.PublishedTime = formatDateParts(w.Published.DateParts[0])
+	}
+	for _, a := range w.Author {
+		name := a.Name
+		if name == "" {
+			name = strings.TrimSpace(a.Given + " " + a.Family)
+		}
+		if name = collapseSpace(name); name != "" {
+			p.Authors = append(p.Authors, name)
+		}
+	}
+	for _, l := range w.Link {
+		if l.ContentType == "application/pdf" {
+			p.Pdf = l.URL
+			break
+		}
+	}
+	return p, true
+}
+
+// [2024, 5, 29] → 2024-05-29，缺少月/日时补 01
+func formatDateParts(parts []int) string {
+	if len(parts) == 0 || parts[0] == 0 {
+		return ""
+	}
+	date := []int{parts[0], 1, 1}
+	copy(date, parts)
+	return fmt.Sprintf("%04d-%02d-%02d", date[0], date[1], date[2])
+}
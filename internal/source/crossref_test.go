@@ -0,0 +1,106 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCrossrefSearchMapsWorks(t *testing.T) {
+	f, srv := newJSONFixture(t, "crossref_works.json")
+	c := NewCrossref(srv.URL+"/", srv.Client())
+	c.Mailto = "ops@example.org"
+
+	papers, err := c.Search(context.Background(), NewQuery("GROMACS", []string{"gmx"}, nil))
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	// 没有标题的条目被跳过；不足一页时不再翻页
+	if len(papers) != 1 {
+		t.Fatalf("got %d papers, want 1", len(papers))
+	}
+	if len(f.requests) != 1 {
+		t.Errorf("got %d requests, want 1", len(f.requests))
+	}
+	q := f.requests[0].URL.Query()
+	if f.requests[0].URL.Path != "/works" || q.Get("cursor") != "*" || q.Get("rows") != "100" ||
+		q.Get("mailto") != "ops@example.org" || q.Get("query.bibliographic") == "" {
+		t.Errorf("unexpected request %s", f.requests[0].URL)
+	}
+
+	p := papers[0]
+	want := map[string]any{
+		"ID":            "doi:10.1145/3458817.3476137",
+		"Source":        "crossref",
+		"DOI":           "10.1145/3458817.3476137",
+		"Title":         "GROMACS on heterogeneous nodes",
+		"Abstract":      "We describe GROMACS performance on GPUs.",
+		"URL":           "http://dx.doi.org/10.1145/3458817.3476137",
+		"Pdf":           "https://dl.acm.org/doi/pdf/10.1145/3458817.3476137",
+		"JournalRef":    "Proceedings of SC21",
+		"PublishedTime": "2021-11-01",
+		"Authors":       []string{"Szilárd Páll", "Hess", "GROMACS Development Team"},
+		"Categories":    []string{"Computer Science"},
+		"ExternalIDs":   map[string]string{"doi": "10.1145/3458817.3476137"},
+	}
+	v := reflect.ValueOf(p)
+	for field, w := range want {
+		if got := v.FieldByName(field).Interface(); !reflect.DeepEqual(got, w) {
+			t.Errorf("%s = %#v, want %#v", field, got, w)
+		}
+	}
+}
+
+func TestCrossrefFetch(t *testing.T) {
+	f, srv := newJSONFixture(t, "crossref_work.json")
+	c := NewCrossref(srv.URL, srv.Client())
+
+	p, err := c.Fetch(context.Background(), "doi:10.5555/ABC.2020")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got := f.requests[0].URL.EscapedPath(); got != "/works/10.5555%2Fabc.2020" {
+		t.Errorf("path = %q", got)
+	}
+	// DOI 统一小写，没有 URL 时指向 doi.org
+	if p.ID != "doi:10.5555/abc.2020" || p.URL != "https://doi.org/10.5555/abc.2020" || p.PublishedTime != "2020-02-03" {
+		t.Errorf("got %+v", p)
+	}
+	if !reflect.DeepEqual(p.Authors, []string{"Ada Lovelace"}) {
+		t.Errorf("authors = %v", p.Authors)
+	}
+
+	f.status = http.StatusNotFound
+	if _, err := c.Fetch(context.Background(), "10.5555/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCrossrefSearchError(t *testing.T) {
+	f, srv := newJSONFixture(t, "crossref_works.json")
+	f.status = http.StatusServiceUnavailable
+	c := NewCrossref(srv.URL, srv.Client())
+
+	if _, err := c.Search(context.Background(), NewQuery("GROMACS", nil, nil)); err == nil {
+		t.Error("err = nil, want the first page error")
+	}
+}
+
+func TestFormatDateParts(t *testing.T) {
+	for _, tc := range []struct {
+		parts []int
+		want  string
+	}{
+		{[]int{2024, 5, 29}, "2024-05-29"},
+		{[]int{2024, 5}, "2024-05-01"},
+		{[]int{2024}, "2024-01-01"},
+		{[]int{0}, ""},
+		{nil, ""},
+	} {
+		if got := formatDateParts(tc.parts); got != tc.want {
+			t.Errorf("formatDateParts(%v) = %q, want %q", tc.parts, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hpc-site/internal/models"
+)
+
+const (
+	DefaultOpenAlexURL = "https://api.openalex.org"
+	openAlexPageSize   = 100
+	openAlexMaxResults = 500
+)
+
+// OpenAlex API 客户端，论文 ID 使用 "openalex:" 前缀的 Work ID（如 openalex:W2741809807）
+type OpenAlex struct {
+	BaseURL    string
+	Mailto     string
+	MaxResults int
+	Client     Doer
+}
+
+func NewOpenAlex(baseURL string, client Doer) *OpenAlex {
+	if baseURL == "" {
+		baseURL = DefaultOpenAlexURL
+	}
+	return &OpenAlex{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		MaxResults: openAlexMaxResults,
+		Client:     defaultDoer(client),
+	}
+}
+
+func (o *OpenAlex) Name() string { return "openalex" }
+
+type openAlexWork struct {
+	ID              string `json:"id"`
+	DOI             string `json:"doi"`
+	DisplayName     string `json:"display_name"`
+	PublicationDate string `json:"publication_date"`
+	IDs             struct {
+		OpenAlex string `json:"openalex"`
+		DOI      string `json:"doi"`
+	} `json:"ids"`
+	Authorships []struct {
+		Author struct {
+			DisplayName string `json:"display_name"`
+		} `json:"author"`
+	} `json:"authorships"`
+	AbstractInvertedIndex map[string][]int `json:"abstract_inverted_index"`
+	PrimaryLocation       *struct {
+		LandingPageURL string `json:"landing_page_url"`
+		PDFURL         string `json:"pdf_url"`
+		Source         *struct {
+			DisplayName string `json:"display_name"`
+		} `json:"source"`
+	} `json:"primary_location"`
+	Concepts []struct {
+		DisplayName string  `json:"display_name"`
+		Score       float64 `json:"score"`
+	} `json:"concepts"`
+	IsRetracted bool `json:"is_retracted"`
+}
+
+// 检索与软件相关的论文，按 cursor 深度分页，最多 MaxResults 条
+func (o *OpenAlex) Search(ctx context.Context, q Query) ([]models.Paper, error) {
+	var papers []models.Paper
+	cursor := "*"
+	for len(papers) < o.MaxResults {
+		params := url.Values{}
+		params.Set("search", q.boolean())
+		params.Set("per-page", strconv.Itoa(openAlexPageSize))
+		params.Set("cursor", cursor)
+		if o.Mailto != "" {
+			params.Set("mailto", o.Mailto)
+		}
+
+		var resp struct {
+			Meta struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"meta"`
+			Results []openAlexWork `json:"results"`
+		}
+		if err := getJSON(ctx, o.Client, o.BaseURL+"/works?"+params.Encode(), &resp); err != nil {
+			if len(papers) == 0 {
+				return nil, fmt.Errorf("openalex: %w", err)
+			}
+			break
+		}
+		for _, w := range resp.Results {
+			if p, ok := w.paper(); ok {
+				papers = append(papers, p)
+			}
+		}
+		if len(resp.Results) < openAlexPageSize || resp.Meta.NextCursor == "" {
+			break
+		}
+		cursor = resp.Meta.NextCursor
+	}
+	if len(papers) > o.MaxResults {
+		papers = papers[:o.MaxResults]
+	}
+	return papers, nil
+}
+
+// 按 Work ID 获取单篇论文，id 可带 "openalex:" 前缀，也可以是 "doi:..."
+func (o *OpenAlex) Fetch(ctx context.Context, id string) (models.Paper, error) {
+	key := strings.TrimPrefix(id, "openalex:")
+	if strings.HasPrefix(key, "doi:") {
+		key = "doi:" + normalizeDOI(key)
+	}
+	u := o.BaseURL + "/works/" + url.PathEscape(key)
+	if o.Mailto != "" {
+		u += "?mailto=" + url.QueryEscape(o.Mailto)
+	}
+
+	var w openAlexWork
+	if err := getJSON(ctx, o.Client, u, &w); err != nil {
+		return models.Paper{}, fmt.Errorf("openalex %s: %w", key, err)
+	}
+	p, ok := w.paper()
+	if !ok {
+		return models.Paper{}, fmt.Errorf("%w: openalex %s", ErrNotFound, key)
+	}
+	return p, nil
+}
+
+var _ PaperSource = (*OpenAlex)(nil)
+
+func (w *openAlexWork) paper() (models.Paper, bool) {
+	workID := w.ID
+	if i := strings.LastIndex(workID, "/"); i >= 0 {
+		workID = workID[i+1:]
+	}
+	if workID == "" || strings.TrimSpace(w.DisplayName) == "" {
+		return models.Paper{}, false
+	}
+
+	p := models.Paper{
+		ID:            "openalex:" + workID,
+		Source:        "openalex",
+		ExternalIDs:   map[string]string{"openalex": workID},
+		Title:         collapseSpace(w.DisplayName),
+		Abstract:      invertedIndexToText(w.AbstractInvertedIndex),
+		URL:           "https://openalex.org/" + workID,
+		PublishedTime: w.PublicationDate,
+		Withdrawn:     w.IsRetracted,
+		Authors:       make([]string, 0, len(w.Authorships)),
+	}
+	if doi := normalizeDOI(w.DOI); doi != "" {
+		p.DOI = doi
+		p.ExternalIDs["doi"] = doi
+	}
+	if loc := w.PrimaryLocation; loc != nil {
+		if loc.LandingPageURL != "" {
+			p.URL = loc.LandingPageURL
+		}
+		p.Pdf = loc.PDFURL
+		if loc.Source != nil {
+			p.JournalRef = loc.Source.DisplayName
+		}
+	}
+	for _, a := range w.Authorships {
+		if name := collapseSpace(a.Author.DisplayName); name != "" {
+			p.Authors = append(p.Authors, name)
+		}
+	}
+	for _, c := range w.Concepts {
+		if c.Score >= 0.5 {
+			p.Categories = append(p.Categories, c.DisplayName)
+		}
+	}
+	return p, true
+}
+
+// OpenAlex 以倒排索引 {词: [位置...]} 的形式提供摘要，这里还原成原文
+func invertedIndexToText(index map[string][]int) string {
+	type wordPos struct {
+		word string
+		pos  int
+	}
+	var words []wordPos
+	for word, positions := range index {
+		for _, pos := range positions {
+			words = append(words, wordPos{word, pos})
+		}
+	}
+	sort.Slice(words, func(i, j int) bool { return words[i].pos < words[j].pos })
+
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = w.word
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,95 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAlexSearchMapsWorks(t *testing.T) {
+	f, srv := newJSONFixture(t, "openalex_works.json")
+	o := NewOpenAlex(srv.URL, srv.Client())
+	o.Mailto = "ops@example.org"
+
+	papers, err := o.Search(context.Background(), NewQuery("GROMACS", nil, nil))
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(papers) != 2 {
+		t.Fatalf("got %d papers, want 2", len(papers))
+	}
+	q := f.requests[0].URL.Query()
+	if q.Get("cursor") != "*" || q.Get("per-page") != "100" || q.Get("mailto") != "ops@example.org" || q.Get("search") == "" {
+		t.Errorf("unexpected request %s", f.requests[0].URL)
+	}
+
+	p := papers[0]
+	want := map[string]any{
+		"ID":            "openalex:W2741809807",
+		"Source":        "openalex",
+		"DOI":           "10.7717/peerj.4375",
+		"Title":         "The state of OA: a large-scale analysis",
+		"Abstract":      "Despite growing interest in OA in OA",
+		"URL":           "https://peerj.com/articles/4375",
+		"Pdf":           "https://peerj.com/articles/4375.pdf",
+		"JournalRef":    "PeerJ",
+		"PublishedTime": "2018-02-13",
+		"Withdrawn":     false,
+		"Authors":       []string{"Heather Piwowar", "Jason Priem"},
+		"Categories":    []string{"Computer science"},
+		"ExternalIDs":   map[string]string{"openalex": "W2741809807", "doi": "10.7717/peerj.4375"},
+	}
+	v := reflect.ValueOf(p)
+	for field, w := range want {
+		if got := v.FieldByName(field).Interface(); !reflect.DeepEqual(got, w) {
+			t.Errorf("%s = %#v, want %#v", field, got, w)
+		}
+	}
+
+	// 撤稿的论文标记为 withdrawn；没有 DOI 和 primary_location 时使用 OpenAlex 页面
+	r := papers[1]
+	if !r.Withdrawn || r.DOI != "" || r.URL != "https://openalex.org/W1000000001" || r.Abstract != "" {
+		t.Errorf("retracted work = %+v", r)
+	}
+}
+
+func TestOpenAlexFetch(t *testing.T) {
+	f, srv := newJSONFixture(t, "openalex_work.json")
+	o := NewOpenAlex(srv.URL, srv.Client())
+	ctx := context.Background()
+
+	p, err := o.Fetch(ctx, "openalex:W2741809807")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if p.ID != "openalex:W2741809807" || p.DOI != "10.7717/peerj.4375" {
+		t.Errorf("got %+v", p)
+	}
+
+	// 也可以按 DOI 获取，DOI 规范化后放在 "doi:" 前缀之后
+	if _, err := o.Fetch(ctx, "doi:https://doi.org/10.7717/PEERJ.4375"); err != nil {
+		t.Fatalf("Fetch by DOI: %v", err)
+	}
+	for i, want := range []string{"/works/W2741809807", "/works/doi:10.7717%2Fpeerj.4375"} {
+		if got := f.requests[i].URL.EscapedPath(); got != want {
+			t.Errorf("request %d path = %q, want %q", i, got, want)
+		}
+	}
+
+	f.status = http.StatusNotFound
+	if _, err := o.Fetch(ctx, "openalex:W0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestInvertedIndexToText(t *testing.T) {
+	got := invertedIndexToText(map[string][]int{"b": {1, 3}, "a": {0}, "c": {2}})
+	if got != "a b c b" {
+		t.Errorf("got %q", got)
+	}
+	if got := invertedIndexToText(nil); got != "" {
+		t.Errorf("nil index: got %q", got)
+	}
+}
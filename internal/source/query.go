@@ -0,0 +1,75 @@
+package source
+
+import (
+	"strings"
+)
+
+// 论文检索条件：Terms 为软件名及别名，命中任一即可，多词名称按短语匹配；
+// 含 Exclude 中任一词的结果由支持布尔检索的来源直接排除
+type Query struct {
+	Terms   []string
+	Exclude []string
+}
+
+// 由软件名、别名和排除词构造检索条件，去掉空白项和重复项
+func NewQuery(name string, aliases, exclude []string) Query {
+	return Query{
+		Terms:   uniqueTerms(append([]string{name}, aliases...)),
+		Exclude: uniqueTerms(exclude),
+	}
+}
+
+func uniqueTerms(terms []string) []string {
+	out := make([]string, 0, len(terms))
+	seen := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		// 引号会破坏短语语法，直接去掉
+		t = collapseSpace(strings.ReplaceAll(t, `"`, " "))
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// 用于日志的简短描述
+func (q Query) String() string {
+	s := strings.Join(q.Terms, " | ")
+	if len(q.Exclude) > 0 {
+		s += " -" + strings.Join(q.Exclude, " -")
+	}
+	return s
+}
+
+// arXiv API 的 search_query：(all:"a" OR all:"b c") ANDNOT (all:"x")
+func (q Query) arxiv() string {
+	s := "(" + joinPhrases("all:", q.Terms, " OR ") + ")"
+	if len(q.Exclude) > 0 {
+		s += " ANDNOT (" + joinPhrases("all:", q.Exclude, " OR ") + ")"
+	}
+	return s
+}
+
+// OpenAlex search 参数的布尔语法：("a" OR "b c") NOT ("x")
+func (q Query) boolean() string {
+	s := "(" + joinPhrases("", q.Terms, " OR ") + ")"
+	if len(q.Exclude) > 0 {
+		s += " NOT (" + joinPhrases("", q.Exclude, " OR ") + ")"
+	}
+	return s
+}
+
+// Crossref 不支持布尔检索，只能把各名称拼在一起按相关度排序，排除词交给后续的相关度评分
+func (q Query) plain() string {
+	return strings.Join(q.Terms, " ")
+}
+
+func joinPhrases(field string, terms []string, sep string) string {
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		parts = append(parts, field+`"`+t+`"`)
+	}
+	return strings.Join(parts, sep)
+}
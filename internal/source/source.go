@@ -0,0 +1,95 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"hpc-site/internal/models"
+)
+
+var ErrNotFound = errors.New("paper not found")
+
+// 发送 HTTP 请求的客户端，生产环境使用 crawlhttp.Client 以统一限速和重试
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// client 为 nil 时使用不限速的默认客户端
+func defaultDoer(client Doer) Doer {
+	if client == nil {
+		return &http.Client{Timeout: 60 * time.Second}
+	}
+	return client
+}
+
+// 论文来源：按软件名及别名检索、按来源内的 ID 获取单篇论文
+type PaperSource interface {
+	// 来源名称，同时写入 models.Paper.Source
+	Name() string
+	Search(ctx context.Context, q Query) ([]models.Paper, error)
+	Fetch(ctx context.Context, id string) (models.Paper, error)
+}
+
+// 支持增量检索的来源：结果按提交时间倒序，翻到 since 之前提交的论文即停止
+type IncrementalSource interface {
+	PaperSource
+	SearchSince(ctx context.Context, q Query, since time.Time) ([]models.Paper, error)
+}
+
+// 按名称创建论文来源，baseURL 为空时使用官方地址，mailto 用于进入 Crossref/OpenAlex 的 polite pool
+func New(name, baseURL, mailto string, client Doer) (PaperSource, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "arxiv":
+		return NewArxivAPI(baseURL, client), nil
+	case "crossref":
+		c := NewCrossref(baseURL, client)
+		c.Mailto = mailto
+		return c, nil
+	case "openalex":
+		o := NewOpenAlex(baseURL, client)
+		o.Mailto = mailto
+		return o, nil
+	}
+	return nil, fmt.Errorf("unknown paper source: %s", name)
+}
+
+// GET 并解析 JSON 响应，404 返回 ErrNotFound
+func getJSON(ctx context.Context, client Doer, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status %d for %s", resp.StatusCode, u)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// 规范化 DOI：去掉 doi: 和 https://doi.org/ 等前缀（"doi:https://doi.org/..." 两个都去掉）并转小写
+func normalizeDOI(doi string) string {
+	doi = strings.TrimSpace(doi)
+	for _, prefix := range []string{"doi:", "https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/"} {
+		if strings.HasPrefix(strings.ToLower(doi), prefix) {
+			doi = doi[len(prefix):]
+		}
+	}
+	return strings.ToLower(doi)
+}
@@ -0,0 +1,100 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 返回 testdata 下的 JSON 文件，记录收到的请求
+type jsonFixture struct {
+	t        *testing.T
+	file     string
+	status   int
+	requests []*http.Request
+}
+
+func newJSONFixture(t *testing.T, file string) (*jsonFixture, *httptest.Server) {
+	f := &jsonFixture{t: t, file: file, status: http.StatusOK}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *jsonFixture) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r)
+	if f.status != http.StatusOK {
+		w.WriteHeader(f.status)
+		return
+	}
+	body, err := os.ReadFile(filepath.Join("testdata", f.file))
+	if err != nil {
+		f.t.Errorf("read fixture: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func TestNormalizeDOI(t *testing.T) {
+	for in, want := range map[string]string{
+		"10.1145/3458817.3476137":                "10.1145/3458817.3476137",
+		"  10.1000/XYZ.2024.001 ":                "10.1000/xyz.2024.001",
+		"doi:10.1000/ABC":                        "10.1000/abc",
+		"DOI:10.1000/ABC":                        "10.1000/abc",
+		"https://doi.org/10.7717/PEERJ.4375":     "10.7717/peerj.4375",
+		"http://doi.org/10.7717/peerj.4375":      "10.7717/peerj.4375",
+		"https://dx.doi.org/10.7717/peerj.4375":  "10.7717/peerj.4375",
+		"http://dx.doi.org/10.7717/peerj.4375":   "10.7717/peerj.4375",
+		"HTTPS://DOI.ORG/10.7717/peerj.4375":     "10.7717/peerj.4375",
+		"doi:https://doi.org/10.7717/PEERJ.4375": "10.7717/peerj.4375",
+		"":                                       "",
+	} {
+		if got := normalizeDOI(in); got != want {
+			t.Errorf("normalizeDOI(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	f, srv := newJSONFixture(t, "crossref_work.json")
+	ctx := context.Background()
+
+	var v struct {
+		Status string `json:"status"`
+	}
+	if err := getJSON(ctx, srv.Client(), srv.URL, &v); err != nil || v.Status != "ok" {
+		t.Fatalf("getJSON = %v, status %q", err, v.Status)
+	}
+	if accept := f.requests[0].Header.Get("Accept"); accept != "application/json" {
+		t.Errorf("Accept = %q", accept)
+	}
+
+	f.status = http.StatusNotFound
+	if err := getJSON(ctx, srv.Client(), srv.URL, &v); !errors.Is(err, ErrNotFound) {
+		t.Errorf("404: err = %v, want ErrNotFound", err)
+	}
+
+	f.status = http.StatusTooManyRequests
+	err := getJSON(ctx, srv.Client(), srv.URL+"/works", &v)
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "unexpected status 429") {
+		t.Errorf("429: err = %v, want unexpected status 429", err)
+	}
+
+	f.status = http.StatusOK
+	f.file = "arxiv_empty.xml"
+	if err := getJSON(ctx, srv.Client(), srv.URL, &v); err == nil {
+		t.Error("non-JSON body: err = nil, want a decode error")
+	}
+
+	srv.Close()
+	if err := getJSON(ctx, srv.Client(), srv.URL, &v); err == nil {
+		t.Error("closed server: err = nil, want a transport error")
+	}
+}
@@ -1,12 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"hpc-site/internal/config"
 	"hpc-site/internal/handler"
+	"hpc-site/internal/models"
+	"hpc-site/internal/repository"
 	"hpc-site/pkg"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -16,21 +33,168 @@ func main() {
 		log.Println("⚠️ .env 文件未找到，尝试使用系统环境变量")
 	}
 
-	// 初始化数据库（现在是 database/sql）
-	pkg.InitDB()
+	// 配置文件、环境变量、命令行参数合并后统一校验，有无效项时不启动
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("❌ 配置无效:\n%v", err)
+	}
 
-	r := gin.Default()
+	if len(args) > 0 && args[0] == "migrate" {
+		runMigrate(cfg, args[1:])
+		return
+	}
 
-	// 路由
-	r.GET("/softwares", handler.GetSoftware)
-	r.GET("/softwares/:id", handler.GetSoftwareDetail)
-	r.GET("/papers", handler.GetPapers)
-	// benchmark
-	r.GET("/benchmarks", handler.GetBenchmarks)
-	r.GET("/softwares/:id/benchmark", handler.GetBenchmarksBySoftware)
-	r.POST("/crawl/all", handler.GetAllSoftwarePaper)
-	r.POST("/test/single", handler.TestSinglePaper)
+	// 演示模式使用内存存储，不连接数据库，接口与正常模式相同
+	demo := cfg.Server.DemoMode
+
+	var h *handler.Handler
+	if demo {
+		log.Println("⚠️ 演示模式：数据保存在内存中，重启后丢失")
+		h = handler.New(repository.NewMemory(), cfg)
+	} else {
+		// 初始化数据库（现在是 database/sql）
+		pkg.InitDB(cfg.Database)
+		h = handler.New(repository.NewPostgres(pkg.DB, cfg.Database.QueryTimeout), cfg)
+	}
+	h.StartCrawlWorker()
+	h.StartCrawlScheduler()
+	if err := h.BootstrapAdmin(context.Background()); err != nil {
+		log.Fatalf("❌ 登记 ADMIN_API_KEY 失败: %v", err)
+	}
+
+	// debug 时 gin 打印路由表，warn 及以上不记录访问日志
+	if cfg.Server.LogLevel != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+	// 论文 ID 可能含 /（doi:10.1000/xyz、hep-lat/0601001），按编码前的路径匹配路由，参数值再解码
+	r.UseRawPath = true
+	if cfg.Server.LogLevel == "debug" || cfg.Server.LogLevel == "info" {
+		// 探针和指标采集请求太频繁，不记录
+		r.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/healthz", "/readyz", "/metrics"}}))
+	}
+	// 跨域预检在认证之前处理
+	if len(cfg.Server.CORSOrigins) > 0 {
+		r.Use(handler.CORS(cfg.Server.CORSOrigins))
+	}
+	// 处理函数通过 c.Error 返回错误，由 ErrorHandler 统一生成错误响应；Recovery 在其内层，panic 也走同样的响应。
+	// Metrics 在 ErrorHandler 外层，记录的是最终返回的状态码
+	r.Use(handler.Metrics(), handler.RequestID(), handler.ErrorHandler(), handler.Recovery(), h.Authenticate())
+	r.NoRoute(handler.NoRoute)
+
+	// 存活和就绪探针：数据库不可用或有未执行的迁移时不接收流量，演示模式下没有依赖
+	var readiness []handler.ReadinessCheck
+	if !demo {
+		readiness = append(readiness,
+			handler.ReadinessCheck{Name: "database", Check: pkg.Ping},
+			handler.ReadinessCheck{Name: "migrations", Check: pkg.CheckMigrations},
+		)
+		r.GET("/admin/db/stats", handler.RequireRole(models.RoleAdmin), handler.DBStats(pkg.DB))
+		prometheus.MustRegister(collectors.NewDBStatsCollector(pkg.DB, "hpc"))
+	}
+	r.GET("/healthz", handler.Healthz)
+	r.GET("/readyz", handler.Readyz(readiness...))
+	// Prometheus 指标：HTTP 请求、连接池和抓取，和探针一样公开访问，由网络策略限制采集来源
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	log.Println("🚀 服务器启动: http://localhost:8080")
-	r.Run(":8080")
+	// 业务接口，按角色授权
+	h.RegisterRoutes(r)
+
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Printf("🚀 服务器启动: %s", cfg.Server.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("❌ 服务器退出: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	// 恢复默认的信号处理，再次收到信号时立即退出
+	stop()
+	log.Printf("🛑 收到退出信号，最多等待 %s 处理完请求并保存抓取进度", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// 停止接收新请求并等待处理中的请求，同时中断抓取任务保存断点，两者都结束后再关闭数据库连接
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("⚠️ 仍有请求未处理完: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := h.StopCrawling(shutdownCtx); err != nil {
+			log.Printf("⚠️ 抓取任务未能及时停止: %v", err)
+		}
+	}()
+	wg.Wait()
+	if !demo {
+		pkg.CloseDB()
+	}
+	log.Println("👋 服务器已退出")
+}
+
+// hpc-site [flags] migrate up | down [n] | status | to <version>
+func runMigrate(cfg *config.Config, args []string) {
+	usage := "用法: hpc-site migrate up | down [n] | status | to <version>"
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+
+	pkg.ConnectDB(cfg.Database)
+	ctx := context.Background()
+	var n int
+	var err error
+	switch args[0] {
+	case "up":
+		n, err = pkg.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				log.Fatalf("❌ 回滚个数 %q 无效", args[1])
+			}
+		}
+		n, err = pkg.MigrateDown(ctx, steps)
+	case "to":
+		if len(args) < 2 {
+			log.Fatal(usage)
+		}
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil || version < 0 {
+			log.Fatalf("❌ 版本号 %q 无效", args[1])
+		}
+		n, err = pkg.MigrateTo(ctx, version)
+	case "status":
+		status, err := pkg.MigrationStatus(ctx)
+		if err != nil {
+			log.Fatalf("❌ 查询迁移状态失败: %v", err)
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s  %s\n", s.Version, s.Name, applied)
+		}
+		return
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatalf("❌ 数据库迁移失败: %v", err)
+	}
+	log.Printf("✅ 迁移完成，共执行 %d 个", n)
 }
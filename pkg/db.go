@@ -1,32 +1,104 @@
 package pkg
 
 import (
+	"context"
 	"database/sql"
 	"log"
-	"os"
+	"time"
+
+	"hpc-site/internal/config"
 
 	_ "github.com/lib/pq" // Postgres driver
 )
 
 var DB *sql.DB
 
-func InitDB() {
-	// 从环境变量读取 DATABASE_URL
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
+// 连接数据库，database.auto_migrate（默认 true）时执行未应用的迁移；
+// 关闭自动迁移时只提示待执行的迁移，需手动执行 migrate up
+func InitDB(cfg config.Database) {
+	ConnectDB(cfg)
+
+	ctx := context.Background()
+	if cfg.AutoMigrate {
+		if _, err := MigrateUp(ctx); err != nil {
+			log.Fatalf("❌ 数据库迁移失败: %v", err)
+		}
+		return
+	}
+
+	status, err := MigrationStatus(ctx)
+	if err != nil {
+		log.Fatalf("❌ 查询迁移状态失败: %v", err)
+	}
+	pending := 0
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		log.Printf("⚠️ 有 %d 个迁移未执行（DB_AUTO_MIGRATE=false），请执行 migrate up", pending)
+	}
+}
+
+// 只连接数据库，不执行迁移
+func ConnectDB(cfg config.Database) {
+	if cfg.URL == "" {
 		log.Fatal("❌ DATABASE_URL 未设置")
 	}
 
 	var err error
-	DB, err = sql.Open("postgres", dsn)
+	DB, err = sql.Open("postgres", cfg.URL)
 	if err != nil {
 		log.Fatalf("❌ 数据库连接失败: %v", err)
 	}
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	DB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	// 测试连接
-	if err := DB.Ping(); err != nil {
+	if err := pingWithRetry(cfg.ConnectTimeout); err != nil {
 		log.Fatalf("❌ 数据库不可用: %v", err)
 	}
 
 	log.Println("✅ 数据库连接成功")
 }
+
+// 启动时数据库可能还没就绪（如和数据库一起重启），按指数退避重试，超过 timeout 仍失败才返回错误；
+// timeout 为 0 时只尝试一次
+func pingWithRetry(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err := Ping(context.Background())
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		log.Printf("⚠️ 数据库不可用（第 %d 次）: %v，%s 后重试", attempt, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// 关闭连接池，应在所有使用数据库的请求和任务结束后调用
+func CloseDB() {
+	if DB == nil {
+		return
+	}
+	if err := DB.Close(); err != nil {
+		log.Printf("⚠️ 关闭数据库连接失败: %v", err)
+		return
+	}
+	log.Println("🗄️ 数据库连接已关闭")
+}
+
+// 检查数据库是否可用，最多等待 5 秒
+func Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return DB.PingContext(ctx)
+}
@@ -0,0 +1,313 @@
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"hpc-site/db"
+)
+
+// 迁移时持有的 advisory lock key，多个实例同时启动时只有一个在执行迁移，其余等待
+const migrationLockKey int64 = 0x6870632d6d696772 // "hpc-migr"
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// 一个版本化迁移，Down 为空时不能回滚
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// 迁移的执行状态，AppliedAt 为空表示未执行
+type MigrationState struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// 读取 db/migrations 下内嵌的迁移脚本，按版本号升序
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(db.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", e.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(db.Migrations, "migrations/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// 执行全部未执行的迁移，返回执行的个数
+func MigrateUp(ctx context.Context) (int, error) {
+	return MigrateTo(ctx, -1)
+}
+
+// 按版本号从新到旧回滚最近 steps 个已执行的迁移，返回回滚的个数
+func MigrateDown(ctx context.Context, steps int) (int, error) {
+	n := 0
+	err := withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int64]time.Time) error {
+		for i := len(migrations) - 1; i >= 0 && n < steps; i-- {
+			if _, ok := applied[migrations[i].Version]; !ok {
+				continue
+			}
+			if err := applyMigration(ctx, conn, migrations[i], false); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// 迁移到指定版本：执行不超过 version 的未执行迁移，回滚超过 version 的已执行迁移；
+// version 为 0 时回滚全部，小于 0 时执行全部。返回执行和回滚的总个数
+func MigrateTo(ctx context.Context, version int64) (int, error) {
+	n := 0
+	err := withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int64]time.Time) error {
+		if version > 0 && !hasMigration(migrations, version) {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+		for i := len(migrations) - 1; i >= 0 && version >= 0; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok || m.Version <= version {
+				continue
+			}
+			if err := applyMigration(ctx, conn, m, false); err != nil {
+				return err
+			}
+			n++
+		}
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok || (version >= 0 && m.Version > version) {
+				continue
+			}
+			if err := applyMigration(ctx, conn, m, true); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// 全部迁移的执行状态，按版本号升序；数据库中有而程序中没有的版本也会列出
+func MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	var status []MigrationState
+	err := withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int64]time.Time) error {
+		rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		names := map[int64]string{}
+		for rows.Next() {
+			var s MigrationState
+			var at time.Time
+			if err := rows.Scan(&s.Version, &s.Name, &at); err != nil {
+				return err
+			}
+			names[s.Version] = s.Name
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			s := MigrationState{Version: m.Version, Name: m.Name}
+			if at, ok := applied[m.Version]; ok {
+				s.AppliedAt = &at
+			}
+			status = append(status, s)
+			delete(names, m.Version)
+		}
+		for v, name := range names {
+			at := applied[v]
+			status = append(status, MigrationState{Version: v, Name: name, AppliedAt: &at})
+		}
+		sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+		return nil
+	})
+	return status, err
+}
+
+// 程序中有而数据库中尚未执行的迁移版本，不获取迁移锁，可用于就绪检查
+func PendingMigrations(ctx context.Context) ([]int64, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := DB.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]bool{}
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []int64
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m.Version)
+		}
+	}
+	return pending, nil
+}
+
+// 全部迁移都已执行时返回 nil
+func CheckMigrations(ctx context.Context) error {
+	pending, err := PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d migrations pending, first %d", len(pending), pending[0])
+	}
+	return nil
+}
+
+func hasMigration(migrations []Migration, version int64) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// 在专用连接上持有 advisory lock，确保 schema_migrations 存在后执行 fn
+func withMigrationLock(ctx context.Context, fn func(conn *sql.Conn, migrations []Migration, applied map[int64]time.Time) error) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return err
+	}
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var v int64
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[v] = at
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return fn(conn, migrations, applied)
+}
+
+// 在一个事务中执行迁移脚本并更新 schema_migrations
+func applyMigration(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	script, direction := m.Up, "up"
+	if !up {
+		script, direction = m.Down, "down"
+		if script == "" {
+			return fmt.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 不带参数时 lib/pq 走简单查询协议，一个脚本里可以有多条语句
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", m.Version, m.Name, direction, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("🗄️ 迁移 %04d_%s %s 完成", m.Version, m.Name, direction)
+	return nil
+}